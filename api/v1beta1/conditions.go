@@ -0,0 +1,97 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionReady - all services of the control plane are deployed and report ready
+	ConditionReady = "Ready"
+	// ConditionReconciling - the operator is still rolling out changes to the control plane
+	ConditionReconciling = "Reconciling"
+	// ConditionDegraded - the last reconcile failed, see the condition message for details
+	ConditionDegraded = "Degraded"
+	// ConditionCredentialsValid - all user supplied credential Secrets exist and provide the required keys
+	ConditionCredentialsValid = "CredentialsValid"
+	// ConditionAvailable - the minimum number of replicas of a Deployment is available
+	ConditionAvailable = "Available"
+)
+
+// Condition mirrors the upstream metav1.Condition, which is not available
+// in the apimachinery version this operator builds against. The json layout
+// is identical so it can be swapped for metav1.Condition once we bump.
+type Condition struct {
+	// type of condition in CamelCase
+	Type string `json:"type"`
+	// status of the condition, one of True, False, Unknown
+	Status metav1.ConditionStatus `json:"status"`
+	// observedGeneration is the .metadata.generation the condition was set based upon
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// lastTransitionTime is the last time the condition transitioned from one status to another
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// reason contains a programmatic identifier indicating the reason for the condition's last transition
+	Reason string `json:"reason,omitempty"`
+	// message is a human readable message indicating details about the transition
+	Message string `json:"message,omitempty"`
+}
+
+// SetCondition - sets newCondition in conditions. The LastTransitionTime is
+// only updated when the status of an existing condition changes.
+func SetCondition(conditions *[]Condition, newCondition Condition) {
+	if conditions == nil {
+		return
+	}
+	existing := FindCondition(*conditions, newCondition.Type)
+	if existing == nil {
+		if newCondition.LastTransitionTime.IsZero() {
+			newCondition.LastTransitionTime = metav1.Now()
+		}
+		*conditions = append(*conditions, newCondition)
+		return
+	}
+
+	if existing.Status != newCondition.Status {
+		existing.Status = newCondition.Status
+		if !newCondition.LastTransitionTime.IsZero() {
+			existing.LastTransitionTime = newCondition.LastTransitionTime
+		} else {
+			existing.LastTransitionTime = metav1.Now()
+		}
+	}
+
+	existing.Reason = newCondition.Reason
+	existing.Message = newCondition.Message
+	existing.ObservedGeneration = newCondition.ObservedGeneration
+}
+
+// FindCondition - returns the condition of type conditionType, nil if not present
+func FindCondition(conditions []Condition, conditionType string) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue - returns true if the condition of type conditionType is present and True
+func IsConditionTrue(conditions []Condition, conditionType string) bool {
+	c := FindCondition(conditions, conditionType)
+	return c != nil && c.Status == metav1.ConditionTrue
+}
@@ -17,70 +17,281 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// MariaDBSpec defines the desired state of MariaDB
+type MariaDBSpec struct {
+	// deploy MariaDB, defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+	// name of a Secret providing the DbRootPassword, generated if not set
+	Secret string `json:"secret,omitempty"`
+	// PVC of the databases
+	Storage StorageSpec `json:"storage,omitempty"`
+	// hostname of an existing database server the services use when MariaDB is disabled
+	ExternalHostname string `json:"externalHostname,omitempty"`
+}
+
 // KeystoneSpec defines the desired state of KeystoneAPI
 type KeystoneSpec struct {
-	// number of Keystone API replicas
-	Replicas int `json:"replicas,omitempty"`
+	// deploy Keystone, defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+	// number of Keystone API replicas, defaults to 1
+	Replicas *int32 `json:"replicas,omitempty"`
+	// name of a Secret providing the AdminPassword and DatabasePassword, generated if not set
+	Secret string `json:"secret,omitempty"`
+	// oslo.config overrides of the service
+	CustomServiceConfigSpec `json:",inline"`
 }
 
 // GlanceSpec defines the desired state of GlanceAPI
 type GlanceSpec struct {
-	// number of Glance API replicas
-	Replicas int `json:"replicas,omitempty"`
+	// deploy Glance, defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+	// number of Glance API replicas, defaults to 1
+	Replicas *int32 `json:"replicas,omitempty"`
+	// name of a Secret providing the DatabasePassword and GlanceKeystoneAuthPassword, generated if not set
+	Secret string `json:"secret,omitempty"`
+	// storage backend of the images
+	Backend GlanceBackendSpec `json:"backend,omitempty"`
+	// oslo.config overrides of the service
+	CustomServiceConfigSpec `json:",inline"`
+}
+
+// GlanceBackendType - storage backend of the Glance images
+type GlanceBackendType string
+
+const (
+	// GlanceBackendFile - images are stored on a PVC
+	GlanceBackendFile GlanceBackendType = "file"
+	// GlanceBackendRBD - images are stored in a Ceph pool
+	GlanceBackendRBD GlanceBackendType = "rbd"
+	// GlanceBackendSwift - images are stored in Swift
+	GlanceBackendSwift GlanceBackendType = "swift"
+	// GlanceBackendS3 - images are stored in a S3 compatible object store
+	GlanceBackendS3 GlanceBackendType = "s3"
+)
+
+// GlanceBackendSpec defines where Glance stores the images
+type GlanceBackendSpec struct {
+	// file, rbd, swift or s3, defaults to file. Can not be changed after creation.
+	// +kubebuilder:validation:Enum=file;rbd;swift;s3
+	Type GlanceBackendType `json:"type,omitempty"`
+	// PVC of the file backend
+	Storage StorageSpec `json:"storage,omitempty"`
+	// name of a Secret providing the credentials of the backend, ceph.conf and keyring
+	// for rbd, AccessKey and SecretKey for s3. Swift uses the keystone credentials of glance.
+	Secret string `json:"secret,omitempty"`
+	// Ceph pool of the rbd backend, defaults to images
+	RBDPool string `json:"rbdPool,omitempty"`
+	// endpoint url of the s3 backend
+	S3Endpoint string `json:"s3Endpoint,omitempty"`
+	// bucket of the s3 backend
+	S3Bucket string `json:"s3Bucket,omitempty"`
+}
+
+// StorageSpec defines the PVC of a service
+type StorageSpec struct {
+	// storage class of the PVC, defaults to storage_class. Can not be changed after creation.
+	StorageClass string `json:"storageClass,omitempty"`
+	// requested size of the PVC, defaults to 10G. It can only grow, the PVC gets
+	// expanded if its storage class allows volume expansion.
+	Size string `json:"size,omitempty"`
+	// access mode of the PVC, defaults to ReadWriteOnce. Services with more
+	// than one replica require ReadWriteMany. Can not be changed after creation.
+	// +kubebuilder:validation:Enum=ReadWriteOnce;ReadWriteMany
+	AccessMode corev1.PersistentVolumeAccessMode `json:"accessMode,omitempty"`
+}
+
+// CustomServiceConfigSpec defines oslo.config overrides of a service, e.g. for nova.conf
+type CustomServiceConfigSpec struct {
+	// oslo.config snippet passed to the service, e.g. "[DEFAULT]\ndebug = true"
+	CustomServiceConfig string `json:"customServiceConfig,omitempty"`
+	// ConfigMap providing the oslo.config snippet, can not be combined with customServiceConfig
+	CustomServiceConfigMap ConfigMapReference `json:"customServiceConfigMap,omitempty"`
+}
+
+// ConfigMapReference defines a key of a ConfigMap in the namespace of the ControlPlane
+type ConfigMapReference struct {
+	// name of the ConfigMap
+	Name string `json:"name,omitempty"`
+	// key of the ConfigMap, defaults to custom.conf
+	Key string `json:"key,omitempty"`
 }
 
 // PlacementSpec defines the desired state of PlacementAPI
 type PlacementSpec struct {
-	// number of Placement API replicas
-	Replicas int `json:"replicas,omitempty"`
+	// deploy Placement, defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+	// number of Placement API replicas, defaults to 1
+	Replicas *int32 `json:"replicas,omitempty"`
+	// name of a Secret providing the DatabasePassword and PlacementKeystoneAuthPassword, generated if not set
+	Secret string `json:"secret,omitempty"`
+	// oslo.config overrides of the service
+	CustomServiceConfigSpec `json:",inline"`
 }
 
 // InterconnectSpec defines the desired state of Interconnect
 type InterconnectSpec struct {
-	// number of Interconnect
-	Replicas int `json:"replicas,omitempty"`
+	// deploy AMQ Interconnect, defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+	// number of Interconnect, defaults to 1. Has to be at least 1.
+	Replicas *int32 `json:"replicas,omitempty"`
+	// name of a Secret providing the passwords of the osp messaging user and of each nova cell, keyed by the cell name, generated if not set
+	Secret string `json:"secret,omitempty"`
+	// host of an existing AMQP endpoint the services use when Interconnect is disabled,
+	// it has to provide the messaging users of the Secret
+	ExternalHost string `json:"externalHost,omitempty"`
+	// port of the external AMQP endpoint, defaults to 5672
+	ExternalPort int `json:"externalPort,omitempty"`
 }
 
 // NovaSpec defines the desired state of Nova Control Plane
 type NovaSpec struct {
-	// number of Nova API replicas
-	NovaAPIReplicas int `json:"novaAPIReplicas,omitempty"`
-	// number of Nova Scheduler replicas
-	NovaSchedulerReplicas int `json:"novaSchedulerReplicas,omitempty"`
-	// number of Nova Conductor replicas
-	NovaConductorReplicas int `json:"novaConductorReplicas,omitempty"`
-	// number of Nova Metadata replicas
-	NovaMetadataReplicas int `json:"novaMetadataReplicas,omitempty"`
-	// number of Nova NoVNCProxy replicas
-	NovaNoVNCProxyReplicas int `json:"novaNoVNCProxyReplicas,omitempty"`
+	// deploy Nova, defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+	// number of Nova API replicas, defaults to 1
+	NovaAPIReplicas *int32 `json:"novaAPIReplicas,omitempty"`
+	// number of Nova Scheduler replicas, defaults to 1
+	NovaSchedulerReplicas *int32 `json:"novaSchedulerReplicas,omitempty"`
+	// number of Nova Conductor replicas, also the default of the cells. Defaults to 1.
+	NovaConductorReplicas *int32 `json:"novaConductorReplicas,omitempty"`
+	// default number of Nova Metadata replicas of the cells, defaults to 1
+	NovaMetadataReplicas *int32 `json:"novaMetadataReplicas,omitempty"`
+	// default number of Nova NoVNCProxy replicas of the cells, defaults to 1
+	NovaNoVNCProxyReplicas *int32 `json:"novaNoVNCProxyReplicas,omitempty"`
+	// name of a Secret providing the DatabasePassword and NovaKeystoneAuthPassword, generated if not set
+	Secret string `json:"secret,omitempty"`
+	// compute cells, defaults to a single cell1. Each cell gets a messaging user and transport url of its own.
+	Cells []NovaCellSpec `json:"cells,omitempty"`
+	// oslo.config overrides of the service
+	CustomServiceConfigSpec `json:",inline"`
+}
+
+// NovaCellSpec defines a compute cell of the Nova Control Plane
+type NovaCellSpec struct {
+	// name of the cell, also used as its messaging user
+	Name string `json:"name"`
+	// host of the cell database, defaults to mariadb
+	DatabaseHostname string `json:"databaseHostname,omitempty"`
+	// messaging vhost of the cell, defaults to the cell name
+	MessagingVhost string `json:"messagingVhost,omitempty"`
+	// number of Nova Conductor replicas of the cell, defaults to novaConductorReplicas
+	NovaConductorReplicas *int32 `json:"novaConductorReplicas,omitempty"`
+	// number of Nova Metadata replicas of the cell, defaults to novaMetadataReplicas
+	NovaMetadataReplicas *int32 `json:"novaMetadataReplicas,omitempty"`
+	// number of Nova NoVNCProxy replicas of the cell, defaults to novaNoVNCProxyReplicas
+	NovaNoVNCProxyReplicas *int32 `json:"novaNoVNCProxyReplicas,omitempty"`
 }
 
 // CinderSpec defines the desired state of Cinder Control Plane
 type CinderSpec struct {
-	// number of Cinder API replicas
-	CinderAPIReplicas int `json:"cinderAPIReplicas,omitempty"`
-	// number of Cinder Scheduler replicas
-	CinderSchedulerReplicas int `json:"cinderSchedulerReplicas,omitempty"`
-	// number of Cinder Backup replicas
-	CinderBackupReplicas int `json:"cinderBackupReplicas,omitempty"`
-	// number of Cinder Volume replicas
-	// Todo: how to handle different cinder volume services
-	CinderVolumeReplicas int `json:"cinderVolumeReplicas,omitempty"`
+	// deploy Cinder, defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+	// number of Cinder API replicas, defaults to 1
+	CinderAPIReplicas *int32 `json:"cinderAPIReplicas,omitempty"`
+	// number of Cinder Scheduler replicas, defaults to 1
+	CinderSchedulerReplicas *int32 `json:"cinderSchedulerReplicas,omitempty"`
+	// number of Cinder Backup replicas, defaults to 0 as cinder-backup requires a backup backend
+	CinderBackupReplicas *int32 `json:"cinderBackupReplicas,omitempty"`
+	// default number of Cinder Volume replicas of the volume backends, defaults to 1
+	CinderVolumeReplicas *int32 `json:"cinderVolumeReplicas,omitempty"`
+	// name of a Secret providing the DatabasePassword and CinderKeystoneAuthPassword, generated if not set
+	Secret string `json:"secret,omitempty"`
+	// volume backends, each gets a cinder-volume service of its own. Defaults to a single volume1.
+	VolumeBackends []CinderVolumeBackendSpec `json:"volumeBackends,omitempty"`
+	// oslo.config overrides of the service
+	CustomServiceConfigSpec `json:",inline"`
+}
+
+// CinderVolumeBackendSpec defines a volume backend of the Cinder Control Plane
+type CinderVolumeBackendSpec struct {
+	// name of the backend, also the name of its cinder-volume service
+	Name string `json:"name"`
+	// number of Cinder Volume replicas of the backend, defaults to cinderVolumeReplicas
+	Replicas *int32 `json:"replicas,omitempty"`
+	// container image of the backend, defaults to images.cinderVolume
+	ContainerImage string `json:"containerImage,omitempty"`
+	// role of the nodes the cinder-volume pods get scheduled on, defaults to worker
+	NodeSelectorRoleName string `json:"nodeSelectorRoleName,omitempty"`
+	// configuration snippet of the backend, e.g. a Ceph RBD, NFS or LVM backend section
+	Config CinderVolumeBackendConfig `json:"config,omitempty"`
+}
+
+// CinderVolumeBackendConfig defines where the configuration snippet of a volume backend is held
+type CinderVolumeBackendConfig struct {
+	// name of a Secret holding the snippet, for backends which need credentials
+	SecretName string `json:"secretName,omitempty"`
+	// name of a ConfigMap holding the snippet
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// key of the snippet in the Secret or ConfigMap, defaults to backend.conf
+	Key string `json:"key,omitempty"`
 }
 
 // NeutronSpec defines the desired state of NeutronAPI
 type NeutronSpec struct {
-	// number of Neutron API replicas
-	Replicas int `json:"replicas,omitempty"`
+	// deploy Neutron, defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+	// number of Neutron API replicas, defaults to 1
+	Replicas *int32 `json:"replicas,omitempty"`
+	// name of a Secret providing the DatabasePassword and NeutronKeystoneAuthPassword, generated if not set
+	Secret string `json:"secret,omitempty"`
+	// oslo.config overrides of the service
+	CustomServiceConfigSpec `json:",inline"`
+}
+
+// ImagesSpec defines the container images of the control plane services,
+// images not set default to the ones shipped with the operator, or to the RELATED_IMAGE_<NAME> env vars
+type ImagesSpec struct {
+	// registry and namespace replacing the ones of all rendered image references,
+	// e.g. registry.example.com/tripleotrain
+	RegistryPrefix string `json:"registryPrefix,omitempty"`
+	// tag replacing the one of all rendered image references, images referenced by digest are kept
+	Tag string `json:"tag,omitempty"`
+	// MariaDB image
+	MariaDB string `json:"mariadb,omitempty"`
+	// Keystone API image
+	Keystone string `json:"keystone,omitempty"`
+	// Glance API image
+	GlanceAPI string `json:"glanceAPI,omitempty"`
+	// Placement API image
+	PlacementAPI string `json:"placementAPI,omitempty"`
+	// Neutron server image
+	NeutronServer string `json:"neutronServer,omitempty"`
+	// Nova API image
+	NovaAPI string `json:"novaAPI,omitempty"`
+	// Nova Scheduler image
+	NovaScheduler string `json:"novaScheduler,omitempty"`
+	// Nova Conductor image
+	NovaConductor string `json:"novaConductor,omitempty"`
+	// Nova Metadata image
+	NovaMetadata string `json:"novaMetadata,omitempty"`
+	// Nova NoVNCProxy image
+	NovaNoVNCProxy string `json:"novaNoVNCProxy,omitempty"`
+	// Cinder API image
+	CinderAPI string `json:"cinderAPI,omitempty"`
+	// Cinder Scheduler image
+	CinderScheduler string `json:"cinderScheduler,omitempty"`
+	// Cinder Backup image
+	CinderBackup string `json:"cinderBackup,omitempty"`
+	// Cinder Volume image
+	CinderVolume string `json:"cinderVolume,omitempty"`
+}
+
+// PruneSpec defines how objects no longer rendered for the ControlPlane get pruned.
+// Individual objects can be excluded by annotating them with controlplane.openstack.org/prune: "false"
+type PruneSpec struct {
+	// only report the objects which would get pruned in the status, without deleting them
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 // ControlPlaneSpec defines the desired state of ControlPlane
 type ControlPlaneSpec struct {
-	// storage class to use for storage claims
+	// default storage class of the storage claims of the services
 	StorageClass string `json:"storage_class,omitempty"`
+	// MariaDB settings
+	MariaDB MariaDBSpec `json:"mariadb,omitempty"`
 	// Keystone API settings
 	Keystone KeystoneSpec `json:"keystone,omitempty"`
 	// Glance API settings
@@ -95,16 +306,140 @@ type ControlPlaneSpec struct {
 	Cinder CinderSpec `json:"cinder,omitempty"`
 	// Neutron settings
 	Neutron NeutronSpec `json:"neutron,omitempty"`
+	// container image overrides
+	Images ImagesSpec `json:"images,omitempty"`
+	// bump to rotate the generated database, keystone and messaging credentials
+	RotationGeneration int64 `json:"rotationGeneration,omitempty"`
+	// pruning of orphaned objects
+	Prune PruneSpec `json:"prune,omitempty"`
+}
+
+// ServiceStatus defines the observed state of a single service of the control plane
+type ServiceStatus struct {
+	// name of the service, e.g. keystone
+	Name string `json:"name"`
+	// kind of the child CR deployed for the service
+	Kind string `json:"kind,omitempty"`
+	// name of the child CR deployed for the service
+	ObjectName string `json:"objectName,omitempty"`
+	// true if the child CR reports the service as deployed
+	Ready bool `json:"ready"`
+	// metadata.generation of the child CR the status was read from
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// reason for the current readiness of the service
+	Reason string `json:"reason,omitempty"`
+	// human readable details on the service state
+	Message string `json:"message,omitempty"`
+	// hash of the custom service configuration the child CR got applied with
+	ConfigHash string `json:"configHash,omitempty"`
+}
+
+const (
+	// RotationSucceeded - the rotated credentials got applied to all services
+	RotationSucceeded = "Succeeded"
+	// RotationFailed - applying the rotated credentials failed, it gets retried
+	RotationFailed = "Failed"
+)
+
+// CredentialsRotationStatus defines the observed state of the credentials rotation
+type CredentialsRotationStatus struct {
+	// rotationGeneration of the last successful rotation, or the one the credentials got generated for
+	RotationGeneration int64 `json:"rotationGeneration,omitempty"`
+	// time of the last rotation attempt
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// outcome of the last rotation attempt, Succeeded or Failed
+	Result string `json:"result,omitempty"`
+	// details on a failed rotation
+	Message string `json:"message,omitempty"`
+}
+
+// RolloutStatus defines the progress of the dependency ordered rollout of the services
+type RolloutStatus struct {
+	// wave of services currently rolled out, starting at 1
+	CurrentWave int32 `json:"currentWave,omitempty"`
+	// total number of waves
+	Waves int32 `json:"waves,omitempty"`
+	// services of the current wave
+	Services []string `json:"services,omitempty"`
+	// time the rollout started waiting for the services of the current wave
+	WaitingSince *metav1.Time `json:"waitingSince,omitempty"`
+}
+
+const (
+	// StorageReady - the PVC provides the requested size
+	StorageReady = "Ready"
+	// StoragePending - the PVC does not exist or is not bound yet
+	StoragePending = "Pending"
+	// StorageExpanding - the PVC got expanded, the volume is being resized
+	StorageExpanding = "Expanding"
+	// StorageFileSystemResizePending - the volume got resized, the file system
+	// gets resized once the pod using the PVC restarts
+	StorageFileSystemResizePending = "FileSystemResizePending"
+	// StorageExpansionNotSupported - the storage class of the PVC does not allow volume expansion
+	StorageExpansionNotSupported = "ExpansionNotSupported"
+)
+
+// StorageStatus defines the observed state of a PVC of a service
+type StorageStatus struct {
+	// name of the service owning the PVC, e.g. mariadb
+	Service string `json:"service"`
+	// name of the PVC
+	ClaimName string `json:"claimName,omitempty"`
+	// storage class of the PVC
+	StorageClass string `json:"storageClass,omitempty"`
+	// size requested in the ControlPlane spec
+	RequestedSize string `json:"requestedSize,omitempty"`
+	// capacity of the bound volume
+	CurrentSize string `json:"currentSize,omitempty"`
+	// Ready, Pending, Expanding, FileSystemResizePending or ExpansionNotSupported
+	State string `json:"state,omitempty"`
+	// human readable details on the state
+	Message string `json:"message,omitempty"`
+}
+
+// PruneStatus defines the outcome of the last pruning of orphaned objects
+type PruneStatus struct {
+	// true if the last pruning ran in dry run mode
+	DryRun bool `json:"dryRun,omitempty"`
+	// objects pruned by the last pruning, or which would have been in dry run mode, as kind/namespace/name
+	Objects []string `json:"objects,omitempty"`
+}
+
+// TeardownStatus defines the progress of the teardown of a deleted ControlPlane
+type TeardownStatus struct {
+	// services currently being deleted
+	Deleting []string `json:"deleting,omitempty"`
+	// services already deleted
+	Deleted []string `json:"deleted,omitempty"`
+	// number of objects still waiting to be deleted
+	PendingObjects int32 `json:"pendingObjects,omitempty"`
 }
 
 // ControlPlaneStatus defines the observed state of ControlPlane
 type ControlPlaneStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// metadata.generation of the ControlPlane last processed by the operator
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Ready, Reconciling and Degraded conditions of the ControlPlane
+	Conditions []Condition `json:"conditions,omitempty"`
+	// status of the individual services of the control plane
+	Services []ServiceStatus `json:"services,omitempty"`
+	// status of the credentials rotation
+	CredentialsRotation CredentialsRotationStatus `json:"credentialsRotation,omitempty"`
+	// progress of the rollout of the services
+	Rollout RolloutStatus `json:"rollout,omitempty"`
+	// outcome of the last pruning of orphaned objects
+	Prune PruneStatus `json:"prune,omitempty"`
+	// size and expansion state of the PVCs of the services
+	Storage []StorageStatus `json:"storage,omitempty"`
+	// progress of the teardown once the ControlPlane got deleted
+	Teardown TeardownStatus `json:"teardown,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].reason"
+// +kubebuilder:printcolumn:name="Wave",type="integer",JSONPath=".status.rollout.currentWave"
 
 // ControlPlane is the Schema for the controlplanes API
 type ControlPlane struct {
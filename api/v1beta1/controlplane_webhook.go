@@ -0,0 +1,540 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+const (
+	// defaultNovaCell - the cell deployed if no cells are set
+	defaultNovaCell = "cell1"
+	// defaultDatabaseHostname - host of the MariaDB deployed for the ControlPlane
+	defaultDatabaseHostname = "mariadb"
+	// defaultCinderVolumeBackend - the volume backend deployed if no backends are set
+	defaultCinderVolumeBackend = "volume1"
+	// defaultNodeSelectorRoleName - role of the nodes services with node affinity get scheduled on
+	defaultNodeSelectorRoleName = "worker"
+	// defaultCinderBackendConfigKey - key of a volume backend configuration snippet
+	defaultCinderBackendConfigKey = "backend.conf"
+	// defaultStorageSize - requested size of the PVCs
+	defaultStorageSize = "10G"
+	// defaultGlanceRBDPool - Ceph pool of the Glance images
+	defaultGlanceRBDPool = "images"
+	// defaultCustomServiceConfigKey - key of a custom service configuration snippet
+	defaultCustomServiceConfigKey = "custom.conf"
+)
+
+// reservedNovaCells - names which can not be used for cells, cell0 is created
+// by nova itself and osp is the messaging user of the top level services
+var reservedNovaCells = map[string]bool{
+	"cell0": true,
+	"osp":   true,
+}
+
+// log is for logging in this package.
+var controlplanelog = logf.Log.WithName("controlplane-resource")
+
+// ControlPlaneDefaults - operator configuration filled in by the defaulting webhook
+type ControlPlaneDefaults struct {
+	// storage class used for the PVCs if none is set
+	StorageClass string
+	// images of the services which are not set in the spec
+	Images ImagesSpec
+}
+
+var controlPlaneDefaults ControlPlaneDefaults
+
+// SetupControlPlaneDefaults - sets the operator configuration used for defaulting, meant to be called once on startup
+func SetupControlPlaneDefaults(defaults ControlPlaneDefaults) {
+	controlPlaneDefaults = defaults
+}
+
+var (
+	// image references as accepted by docker/distribution, e.g. registry:5000/ns/name:tag@sha256:...
+	imageNameRegexp      = `(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?(?:/[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*)*`
+	imageTagRegexp       = `[\w][\w.-]{0,127}`
+	imageDigestRegexp    = `[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}`
+	imageReferenceRegexp = regexp.MustCompile(`^` + imageNameRegexp + `(?::` + imageTagRegexp + `)?(?:@` + imageDigestRegexp + `)?$`)
+	imagePrefixRegexp    = regexp.MustCompile(`^` + imageNameRegexp + `/?$`)
+	imageTagOnlyRegexp   = regexp.MustCompile(`^` + imageTagRegexp + `$`)
+)
+
+// SetupWebhookWithManager -
+func (r *ControlPlane) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-controlplane-openstack-org-v1beta1-controlplane,mutating=true,failurePolicy=fail,groups=controlplane.openstack.org,resources=controlplanes,verbs=create;update,versions=v1beta1,name=mcontrolplane.kb.io
+
+var _ webhook.Defaulter = &ControlPlane{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *ControlPlane) Default() {
+	controlplanelog.Info("default", "name", r.Name)
+
+	if r.Spec.StorageClass == "" {
+		r.Spec.StorageClass = controlPlaneDefaults.StorageClass
+	}
+
+	defaultReplicas(&r.Spec.Keystone.Replicas, 1)
+	defaultReplicas(&r.Spec.Glance.Replicas, 1)
+	defaultReplicas(&r.Spec.Placement.Replicas, 1)
+	defaultReplicas(&r.Spec.Neutron.Replicas, 1)
+	defaultReplicas(&r.Spec.Nova.NovaAPIReplicas, 1)
+	defaultReplicas(&r.Spec.Nova.NovaSchedulerReplicas, 1)
+	defaultReplicas(&r.Spec.Nova.NovaConductorReplicas, 1)
+	defaultReplicas(&r.Spec.Nova.NovaMetadataReplicas, 1)
+	defaultReplicas(&r.Spec.Nova.NovaNoVNCProxyReplicas, 1)
+	defaultReplicas(&r.Spec.Cinder.CinderAPIReplicas, 1)
+	defaultReplicas(&r.Spec.Cinder.CinderSchedulerReplicas, 1)
+	defaultReplicas(&r.Spec.Cinder.CinderVolumeReplicas, 1)
+	// cinder-backup requires a backup backend, it stays disabled unless requested
+	defaultReplicas(&r.Spec.Cinder.CinderBackupReplicas, 0)
+	// required to be greater than 0 by the interconnect operator
+	if r.Spec.Interconnect.Replicas == nil || *r.Spec.Interconnect.Replicas < 1 {
+		one := int32(1)
+		r.Spec.Interconnect.Replicas = &one
+	}
+
+	defaultStorage(&r.Spec.MariaDB.Storage, r.Spec.StorageClass)
+
+	if r.Spec.Glance.Backend.Type == "" {
+		r.Spec.Glance.Backend.Type = GlanceBackendFile
+	}
+	switch r.Spec.Glance.Backend.Type {
+	case GlanceBackendFile:
+		defaultStorage(&r.Spec.Glance.Backend.Storage, r.Spec.StorageClass)
+	case GlanceBackendRBD:
+		if r.Spec.Glance.Backend.RBDPool == "" {
+			r.Spec.Glance.Backend.RBDPool = defaultGlanceRBDPool
+		}
+	}
+
+	if len(r.Spec.Cinder.VolumeBackends) == 0 {
+		r.Spec.Cinder.VolumeBackends = []CinderVolumeBackendSpec{{Name: defaultCinderVolumeBackend}}
+	}
+	for i := range r.Spec.Cinder.VolumeBackends {
+		backend := &r.Spec.Cinder.VolumeBackends[i]
+		if backend.NodeSelectorRoleName == "" {
+			backend.NodeSelectorRoleName = defaultNodeSelectorRoleName
+		}
+		if backend.Config.Key == "" && (backend.Config.SecretName != "" || backend.Config.ConfigMapName != "") {
+			backend.Config.Key = defaultCinderBackendConfigKey
+		}
+	}
+
+	if len(r.Spec.Nova.Cells) == 0 {
+		r.Spec.Nova.Cells = []NovaCellSpec{{Name: defaultNovaCell}}
+	}
+	databaseHostname := defaultDatabaseHostname
+	if !isEnabled(r.Spec.MariaDB.Enabled) && r.Spec.MariaDB.ExternalHostname != "" {
+		databaseHostname = r.Spec.MariaDB.ExternalHostname
+	}
+	for i := range r.Spec.Nova.Cells {
+		cell := &r.Spec.Nova.Cells[i]
+		if cell.DatabaseHostname == "" {
+			cell.DatabaseHostname = databaseHostname
+		}
+		if cell.MessagingVhost == "" {
+			cell.MessagingVhost = cell.Name
+		}
+	}
+
+	for _, config := range r.Spec.CustomServiceConfigs() {
+		if config.CustomServiceConfigMap.Name != "" && config.CustomServiceConfigMap.Key == "" {
+			config.CustomServiceConfigMap.Key = defaultCustomServiceConfigKey
+		}
+	}
+
+	defaults := controlPlaneDefaults.Images.Images()
+	for name, image := range r.Spec.Images.Images() {
+		if *image == "" {
+			*image = *defaults[name]
+		}
+	}
+}
+
+// defaultReplicas - n replicas unless set, an explicit 0 is kept
+func defaultReplicas(replicas **int32, n int32) {
+	if *replicas == nil {
+		*replicas = &n
+	}
+}
+
+// defaultStorage - a ReadWriteOnce PVC of the default size and storage class unless set
+func defaultStorage(storage *StorageSpec, storageClass string) {
+	if storage.StorageClass == "" {
+		storage.StorageClass = storageClass
+	}
+	if storage.Size == "" {
+		storage.Size = defaultStorageSize
+	}
+	if storage.AccessMode == "" {
+		storage.AccessMode = corev1.ReadWriteOnce
+	}
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-controlplane-openstack-org-v1beta1-controlplane,mutating=false,failurePolicy=fail,groups=controlplane.openstack.org,resources=controlplanes,versions=v1beta1,name=vcontrolplane.kb.io
+
+var _ webhook.Validator = &ControlPlane{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ControlPlane) ValidateCreate() error {
+	controlplanelog.Info("validate create", "name", r.Name)
+
+	return r.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *ControlPlane) ValidateUpdate(old runtime.Object) error {
+	controlplanelog.Info("validate update", "name", r.Name)
+
+	return r.validate(old.(*ControlPlane))
+}
+
+// Validate checks the spec on its own, without the checks against a previous
+// spec. The reconciler runs it as the webhooks are not deployed everywhere.
+func (r *ControlPlane) Validate() error {
+	return r.validate(nil)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ControlPlane) ValidateDelete() error {
+	controlplanelog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
+
+// validate checks the spec, old is the ControlPlane before an update, nil on create
+func (r *ControlPlane) validate(old *ControlPlane) error {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	type replicaCount struct {
+		path *field.Path
+		n    *int32
+	}
+	replicas := []replicaCount{
+		{specPath.Child("keystone", "replicas"), r.Spec.Keystone.Replicas},
+		{specPath.Child("glance", "replicas"), r.Spec.Glance.Replicas},
+		{specPath.Child("placement", "replicas"), r.Spec.Placement.Replicas},
+		{specPath.Child("neutron", "replicas"), r.Spec.Neutron.Replicas},
+		{specPath.Child("interconnect", "replicas"), r.Spec.Interconnect.Replicas},
+		{specPath.Child("nova", "novaAPIReplicas"), r.Spec.Nova.NovaAPIReplicas},
+		{specPath.Child("nova", "novaSchedulerReplicas"), r.Spec.Nova.NovaSchedulerReplicas},
+		{specPath.Child("nova", "novaConductorReplicas"), r.Spec.Nova.NovaConductorReplicas},
+		{specPath.Child("nova", "novaMetadataReplicas"), r.Spec.Nova.NovaMetadataReplicas},
+		{specPath.Child("nova", "novaNoVNCProxyReplicas"), r.Spec.Nova.NovaNoVNCProxyReplicas},
+		{specPath.Child("cinder", "cinderAPIReplicas"), r.Spec.Cinder.CinderAPIReplicas},
+		{specPath.Child("cinder", "cinderSchedulerReplicas"), r.Spec.Cinder.CinderSchedulerReplicas},
+		{specPath.Child("cinder", "cinderBackupReplicas"), r.Spec.Cinder.CinderBackupReplicas},
+		{specPath.Child("cinder", "cinderVolumeReplicas"), r.Spec.Cinder.CinderVolumeReplicas},
+	}
+	cellsPath := specPath.Child("nova", "cells")
+	cellNames := map[string]bool{}
+	for i, cell := range r.Spec.Nova.Cells {
+		cellPath := cellsPath.Index(i)
+		for _, msg := range validation.IsDNS1123Label(cell.Name) {
+			allErrs = append(allErrs, field.Invalid(cellPath.Child("name"), cell.Name, msg))
+		}
+		switch {
+		case reservedNovaCells[cell.Name]:
+			allErrs = append(allErrs, field.Invalid(cellPath.Child("name"), cell.Name, "name is reserved"))
+		case cellNames[cell.Name]:
+			allErrs = append(allErrs, field.Duplicate(cellPath.Child("name"), cell.Name))
+		}
+		cellNames[cell.Name] = true
+
+		replicas = append(replicas,
+			replicaCount{cellPath.Child("novaConductorReplicas"), cell.NovaConductorReplicas},
+			replicaCount{cellPath.Child("novaMetadataReplicas"), cell.NovaMetadataReplicas},
+			replicaCount{cellPath.Child("novaNoVNCProxyReplicas"), cell.NovaNoVNCProxyReplicas},
+		)
+	}
+
+	backendsPath := specPath.Child("cinder", "volumeBackends")
+	backendNames := map[string]bool{}
+	for i, backend := range r.Spec.Cinder.VolumeBackends {
+		backendPath := backendsPath.Index(i)
+		for _, msg := range validation.IsDNS1123Label(backend.Name) {
+			allErrs = append(allErrs, field.Invalid(backendPath.Child("name"), backend.Name, msg))
+		}
+		if backendNames[backend.Name] {
+			allErrs = append(allErrs, field.Duplicate(backendPath.Child("name"), backend.Name))
+		}
+		backendNames[backend.Name] = true
+
+		if backend.ContainerImage != "" && !imageReferenceRegexp.MatchString(backend.ContainerImage) {
+			allErrs = append(allErrs, field.Invalid(backendPath.Child("containerImage"), backend.ContainerImage, "invalid image reference"))
+		}
+		configPath := backendPath.Child("config")
+		if backend.Config.SecretName != "" && backend.Config.ConfigMapName != "" {
+			allErrs = append(allErrs, field.Forbidden(configPath.Child("configMapName"), "can not be combined with secretName"))
+		}
+		if backend.Config.Key != "" && backend.Config.SecretName == "" && backend.Config.ConfigMapName == "" {
+			allErrs = append(allErrs, field.Required(configPath, "secretName or configMapName holding the key"))
+		}
+
+		replicas = append(replicas, replicaCount{backendPath.Child("replicas"), backend.Replicas})
+	}
+
+	for _, replica := range replicas {
+		if replica.n != nil && *replica.n < 0 {
+			allErrs = append(allErrs, field.Invalid(replica.path, *replica.n, "must not be negative"))
+		}
+	}
+
+	// mariadb and the glance file backend store their data on PVCs
+	if isEnabled(r.Spec.MariaDB.Enabled) {
+		var oldStorage *StorageSpec
+		if old != nil {
+			oldStorage = &old.Spec.MariaDB.Storage
+		}
+		allErrs = append(allErrs, validateStorage(r.Spec.MariaDB.Storage, oldStorage, specPath.Child("mariadb", "storage"))...)
+	}
+
+	allErrs = append(allErrs, r.validateGlanceBackend(old, specPath.Child("glance"))...)
+	allErrs = append(allErrs, r.validateExternalEndpoints(specPath)...)
+	if old != nil && old.Spec.StorageClass != "" && r.Spec.StorageClass != old.Spec.StorageClass {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("storage_class"), "can not be changed after creation"))
+	}
+
+	configs := r.Spec.CustomServiceConfigs()
+	services := []string{}
+	for service := range configs {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		config := configs[service]
+		configMapPath := specPath.Child(service, "customServiceConfigMap")
+		if config.CustomServiceConfig != "" && config.CustomServiceConfigMap.Name != "" {
+			allErrs = append(allErrs, field.Forbidden(configMapPath, "can not be combined with customServiceConfig"))
+		}
+		if config.CustomServiceConfigMap.Key != "" && config.CustomServiceConfigMap.Name == "" {
+			allErrs = append(allErrs, field.Required(configMapPath.Child("name"), "ConfigMap holding the key"))
+		}
+	}
+
+	imagesPath := specPath.Child("images")
+	images := r.Spec.Images.Images()
+	names := []string{}
+	for name := range images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if image := images[name]; *image != "" && !imageReferenceRegexp.MatchString(*image) {
+			allErrs = append(allErrs, field.Invalid(imagesPath.Child(name), *image, "invalid image reference"))
+		}
+	}
+	if r.Spec.Images.RegistryPrefix != "" && !imagePrefixRegexp.MatchString(r.Spec.Images.RegistryPrefix) {
+		allErrs = append(allErrs, field.Invalid(imagesPath.Child("registryPrefix"), r.Spec.Images.RegistryPrefix, "invalid registry prefix"))
+	}
+	if r.Spec.Images.Tag != "" && !imageTagOnlyRegexp.MatchString(r.Spec.Images.Tag) {
+		allErrs = append(allErrs, field.Invalid(imagesPath.Child("tag"), r.Spec.Images.Tag, "invalid image tag"))
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("ControlPlane").GroupKind(), r.Name, allErrs)
+}
+
+// validateGlanceBackend checks the options of the selected Glance backend
+func (r *ControlPlane) validateGlanceBackend(old *ControlPlane, glancePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	backend := r.Spec.Glance.Backend
+	backendPath := glancePath.Child("backend")
+
+	if old != nil && old.Spec.Glance.Backend.Type != "" && backend.Type != old.Spec.Glance.Backend.Type {
+		allErrs = append(allErrs, field.Forbidden(backendPath.Child("type"), "can not be changed after creation, the stored images would be lost"))
+	}
+
+	if backend.Type == GlanceBackendFile {
+		var oldStorage *StorageSpec
+		if old != nil && old.Spec.Glance.Backend.Type == GlanceBackendFile {
+			oldStorage = &old.Spec.Glance.Backend.Storage
+		}
+		storagePath := backendPath.Child("storage")
+		if isEnabled(r.Spec.Glance.Enabled) {
+			allErrs = append(allErrs, validateStorage(backend.Storage, oldStorage, storagePath)...)
+		}
+		// the replicas share the images PVC
+		if r.Spec.Glance.Replicas != nil && *r.Spec.Glance.Replicas > 1 && backend.Storage.AccessMode != corev1.ReadWriteMany {
+			allErrs = append(allErrs, field.Invalid(storagePath.Child("accessMode"), backend.Storage.AccessMode,
+				"more than one replica requires ReadWriteMany, or a shared backend like rbd, swift or s3"))
+		}
+	} else if backend.Storage != (StorageSpec{}) {
+		allErrs = append(allErrs, field.Forbidden(backendPath.Child("storage"), "only used by the file backend"))
+	}
+
+	switch backend.Type {
+	case GlanceBackendRBD:
+		if backend.Secret == "" {
+			allErrs = append(allErrs, field.Required(backendPath.Child("secret"), "Secret providing the ceph.conf and keyring"))
+		}
+	case GlanceBackendS3:
+		if backend.Secret == "" {
+			allErrs = append(allErrs, field.Required(backendPath.Child("secret"), "Secret providing the AccessKey and SecretKey"))
+		}
+		if backend.S3Endpoint == "" {
+			allErrs = append(allErrs, field.Required(backendPath.Child("s3Endpoint"), "endpoint url of the s3 backend"))
+		}
+		if backend.S3Bucket == "" {
+			allErrs = append(allErrs, field.Required(backendPath.Child("s3Bucket"), "bucket of the s3 backend"))
+		}
+	}
+	return allErrs
+}
+
+// validateStorage checks the storage class, size and access mode of a PVC. On
+// updates only the size may change, and only grow, as the bound PVC gets expanded
+// in place.
+func validateStorage(storage StorageSpec, old *StorageSpec, storagePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if storage.StorageClass == "" {
+		allErrs = append(allErrs, field.Required(storagePath.Child("storageClass"), "set it or the default storage_class"))
+	}
+	size, err := resource.ParseQuantity(storage.Size)
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(storagePath.Child("size"), storage.Size, err.Error()))
+	}
+	if storage.AccessMode != corev1.ReadWriteOnce && storage.AccessMode != corev1.ReadWriteMany {
+		allErrs = append(allErrs, field.NotSupported(storagePath.Child("accessMode"), storage.AccessMode,
+			[]string{string(corev1.ReadWriteOnce), string(corev1.ReadWriteMany)}))
+	}
+	if old == nil {
+		return allErrs
+	}
+
+	if old.StorageClass != "" && storage.StorageClass != old.StorageClass {
+		allErrs = append(allErrs, field.Forbidden(storagePath.Child("storageClass"), "can not be changed after creation"))
+	}
+	if old.AccessMode != "" && storage.AccessMode != old.AccessMode {
+		allErrs = append(allErrs, field.Forbidden(storagePath.Child("accessMode"), "can not be changed after creation"))
+	}
+	if oldSize, oldErr := resource.ParseQuantity(old.Size); err == nil && oldErr == nil && size.Cmp(oldSize) < 0 {
+		allErrs = append(allErrs, field.Forbidden(storagePath.Child("size"), fmt.Sprintf("can not be reduced below %s", old.Size)))
+	}
+	return allErrs
+}
+
+// Images - the image of each service, by json field name
+func (s *ImagesSpec) Images() map[string]*string {
+	return map[string]*string{
+		"mariadb":         &s.MariaDB,
+		"keystone":        &s.Keystone,
+		"glanceAPI":       &s.GlanceAPI,
+		"placementAPI":    &s.PlacementAPI,
+		"neutronServer":   &s.NeutronServer,
+		"novaAPI":         &s.NovaAPI,
+		"novaScheduler":   &s.NovaScheduler,
+		"novaConductor":   &s.NovaConductor,
+		"novaMetadata":    &s.NovaMetadata,
+		"novaNoVNCProxy":  &s.NovaNoVNCProxy,
+		"cinderAPI":       &s.CinderAPI,
+		"cinderScheduler": &s.CinderScheduler,
+		"cinderBackup":    &s.CinderBackup,
+		"cinderVolume":    &s.CinderVolume,
+	}
+}
+
+// validateExternalEndpoints - the enabled services need the endpoints of an
+// external database and messaging when MariaDB or Interconnect are disabled
+func (r *ControlPlane) validateExternalEndpoints(specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	databaseUsers := anyEnabled(r.Spec.Keystone.Enabled, r.Spec.Glance.Enabled, r.Spec.Placement.Enabled,
+		r.Spec.Neutron.Enabled, r.Spec.Cinder.Enabled, r.Spec.Nova.Enabled)
+	messagingUsers := anyEnabled(r.Spec.Glance.Enabled, r.Spec.Neutron.Enabled, r.Spec.Cinder.Enabled, r.Spec.Nova.Enabled)
+
+	mariadbPath := specPath.Child("mariadb")
+	if isEnabled(r.Spec.MariaDB.Enabled) {
+		if r.Spec.MariaDB.ExternalHostname != "" {
+			allErrs = append(allErrs, field.Forbidden(mariadbPath.Child("externalHostname"), "only used when MariaDB is disabled"))
+		}
+	} else {
+		if r.Spec.MariaDB.ExternalHostname == "" && databaseUsers {
+			allErrs = append(allErrs, field.Required(mariadbPath.Child("externalHostname"), "database of the enabled services when MariaDB is disabled"))
+		}
+		for i, cell := range r.Spec.Nova.Cells {
+			if cell.DatabaseHostname == defaultDatabaseHostname {
+				allErrs = append(allErrs, field.Invalid(specPath.Child("nova", "cells").Index(i).Child("databaseHostname"),
+					cell.DatabaseHostname, "MariaDB is disabled"))
+			}
+		}
+	}
+
+	interconnectPath := specPath.Child("interconnect")
+	if r.Spec.Interconnect.ExternalPort < 0 || r.Spec.Interconnect.ExternalPort > 65535 {
+		allErrs = append(allErrs, field.Invalid(interconnectPath.Child("externalPort"), r.Spec.Interconnect.ExternalPort, "invalid port"))
+	}
+	if isEnabled(r.Spec.Interconnect.Enabled) {
+		if r.Spec.Interconnect.ExternalHost != "" {
+			allErrs = append(allErrs, field.Forbidden(interconnectPath.Child("externalHost"), "only used when Interconnect is disabled"))
+		}
+	} else if r.Spec.Interconnect.ExternalHost != "" {
+		if r.Spec.Interconnect.Secret == "" {
+			allErrs = append(allErrs, field.Required(interconnectPath.Child("secret"), "passwords of the messaging users of the external endpoint"))
+		}
+	} else if messagingUsers {
+		allErrs = append(allErrs, field.Required(interconnectPath.Child("externalHost"), "messaging of the enabled services when Interconnect is disabled"))
+	}
+	return allErrs
+}
+
+// CustomServiceConfigs - the oslo.config overrides of each service, by json field name
+func (s *ControlPlaneSpec) CustomServiceConfigs() map[string]*CustomServiceConfigSpec {
+	return map[string]*CustomServiceConfigSpec{
+		"keystone":  &s.Keystone.CustomServiceConfigSpec,
+		"glance":    &s.Glance.CustomServiceConfigSpec,
+		"placement": &s.Placement.CustomServiceConfigSpec,
+		"neutron":   &s.Neutron.CustomServiceConfigSpec,
+		"nova":      &s.Nova.CustomServiceConfigSpec,
+		"cinder":    &s.Cinder.CustomServiceConfigSpec,
+	}
+}
+
+// isEnabled - services are enabled unless explicitly disabled
+func isEnabled(enabled *bool) bool {
+	return enabled == nil || *enabled
+}
+
+// anyEnabled - true if one of the services is enabled
+func anyEnabled(enabled ...*bool) bool {
+	for _, e := range enabled {
+		if isEnabled(e) {
+			return true
+		}
+	}
+	return false
+}
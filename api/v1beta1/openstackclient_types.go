@@ -25,15 +25,24 @@ type OpenStackClientSpec struct {
 	ContainerImage        string `json:"containerImage,omitempty"`
 	OpenStackConfigMap    string `json:"openStackConfigMap,omitempty"`
 	OpenStackConfigSecret string `json:"openStackConfigSecret,omitempty"`
+	// name of a ControlPlane in the same namespace, the clouds.yaml and secure.yaml
+	// get generated from its Keystone endpoint and admin credentials. Can not be
+	// combined with openStackConfigMap and openStackConfigSecret.
+	ControlPlane string `json:"controlPlane,omitempty"`
 }
 
 // OpenStackClientStatus defines the observed state of OpenStackClient
 type OpenStackClientStatus struct {
+	// hash of the clouds.yaml and secure.yaml the client pod got rolled out with
 	DeploymentHash string `json:"deploymentHash"`
+	// Ready and Available conditions of the client Deployment
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].reason"
 
 // OpenStackClient is the Schema for the openstackclients API
 type OpenStackClient struct {
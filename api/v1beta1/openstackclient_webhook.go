@@ -0,0 +1,214 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+const (
+	// OpenStackClientConfigKey - key of the referenced ConfigMap holding the clouds.yaml
+	OpenStackClientConfigKey = "clouds.yaml"
+	// OpenStackClientSecretKey - key of the referenced Secret holding the secure.yaml
+	OpenStackClientSecretKey = "secure.yaml"
+)
+
+// log is for logging in this package.
+var openstackclientlog = logf.Log.WithName("openstackclient-resource")
+
+// OpenStackClientDefaults - operator configuration filled in by the defaulting webhook
+type OpenStackClientDefaults struct {
+	// container image used if none is set
+	ContainerImage string
+}
+
+var openStackClientDefaults OpenStackClientDefaults
+
+// SetupOpenStackClientDefaults - sets the operator configuration used for defaulting, meant to be called once on startup
+func SetupOpenStackClientDefaults(defaults OpenStackClientDefaults) {
+	openStackClientDefaults = defaults
+}
+
+// webhookClient - reads the ConfigMaps and Secrets referenced by an OpenStackClient,
+// the validation of the references is skipped if not set
+var webhookClient client.Reader
+
+// SetupWebhookWithManager -
+func (r *OpenStackClient) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	// not cached, the webhook only reads the objects referenced by the validated OpenStackClient
+	webhookClient = mgr.GetAPIReader()
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-controlplane-openstack-org-v1beta1-openstackclient,mutating=true,failurePolicy=fail,groups=controlplane.openstack.org,resources=openstackclients,verbs=create;update,versions=v1beta1,name=mopenstackclient.kb.io
+
+var _ webhook.Defaulter = &OpenStackClient{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *OpenStackClient) Default() {
+	openstackclientlog.Info("default", "name", r.Name)
+
+	if r.Spec.ContainerImage == "" {
+		r.Spec.ContainerImage = openStackClientDefaults.ContainerImage
+	}
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-controlplane-openstack-org-v1beta1-openstackclient,mutating=false,failurePolicy=fail,groups=controlplane.openstack.org,resources=openstackclients,versions=v1beta1,name=vopenstackclient.kb.io
+
+var _ webhook.Validator = &OpenStackClient{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *OpenStackClient) ValidateCreate() error {
+	openstackclientlog.Info("validate create", "name", r.Name)
+
+	return r.validate(webhookClient)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *OpenStackClient) ValidateUpdate(old runtime.Object) error {
+	openstackclientlog.Info("validate update", "name", r.Name)
+
+	return r.validate(webhookClient)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *OpenStackClient) ValidateDelete() error {
+	openstackclientlog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
+
+// Validate checks the spec and the objects it references, read with c. The
+// reconciler runs it as the webhooks are not deployed everywhere.
+func (r *OpenStackClient) Validate(c client.Reader) error {
+	return r.validate(c)
+}
+
+// validate checks the spec and that the referenced ControlPlane, or ConfigMap
+// and Secret, can provide the clouds.yaml and secure.yaml of the client pod.
+// The references are only checked if c is set.
+func (r *OpenStackClient) validate(c client.Reader) error {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if r.Spec.ContainerImage == "" {
+		allErrs = append(allErrs, field.Required(specPath.Child("containerImage"), "no default image is configured in the operator"))
+	} else if !imageReferenceRegexp.MatchString(r.Spec.ContainerImage) {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("containerImage"), r.Spec.ContainerImage, "invalid image reference"))
+	}
+
+	var errs field.ErrorList
+	var err error
+	if r.Spec.ControlPlane != "" {
+		errs, err = r.validateControlPlane(c, specPath)
+	} else {
+		errs, err = r.validateConfigReferences(c, specPath)
+	}
+	if err != nil {
+		return err
+	}
+	allErrs = append(allErrs, errs...)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("OpenStackClient").GroupKind(), r.Name, allErrs)
+}
+
+// validateControlPlane checks the referenced ControlPlane the configuration gets generated from
+func (r *OpenStackClient) validateControlPlane(c client.Reader, specPath *field.Path) (field.ErrorList, error) {
+	allErrs := field.ErrorList{}
+
+	if r.Spec.OpenStackConfigMap != "" {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("openStackConfigMap"), "can not be combined with controlPlane"))
+	}
+	if r.Spec.OpenStackConfigSecret != "" {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("openStackConfigSecret"), "can not be combined with controlPlane"))
+	}
+
+	if c != nil {
+		controlPlane := &ControlPlane{}
+		err := c.Get(context.TODO(), types.NamespacedName{Name: r.Spec.ControlPlane, Namespace: r.Namespace}, controlPlane)
+		switch {
+		case apierrors.IsNotFound(err):
+			allErrs = append(allErrs, field.NotFound(specPath.Child("controlPlane"), r.Spec.ControlPlane))
+		case err != nil:
+			return nil, err
+		}
+	}
+	return allErrs, nil
+}
+
+// validateConfigReferences checks the user provided ConfigMap and Secret
+func (r *OpenStackClient) validateConfigReferences(c client.Reader, specPath *field.Path) (field.ErrorList, error) {
+	allErrs := field.ErrorList{}
+
+	configMapPath := specPath.Child("openStackConfigMap")
+	if r.Spec.OpenStackConfigMap == "" {
+		allErrs = append(allErrs, field.Required(configMapPath, fmt.Sprintf("name of a ConfigMap providing the %s", OpenStackClientConfigKey)))
+	} else if c != nil {
+		configMap := &corev1.ConfigMap{}
+		err := c.Get(context.TODO(), types.NamespacedName{Name: r.Spec.OpenStackConfigMap, Namespace: r.Namespace}, configMap)
+		switch {
+		case apierrors.IsNotFound(err):
+			allErrs = append(allErrs, field.NotFound(configMapPath, r.Spec.OpenStackConfigMap))
+		case err != nil:
+			return nil, err
+		default:
+			_, inData := configMap.Data[OpenStackClientConfigKey]
+			_, inBinaryData := configMap.BinaryData[OpenStackClientConfigKey]
+			if !inData && !inBinaryData {
+				allErrs = append(allErrs, field.Invalid(configMapPath, r.Spec.OpenStackConfigMap,
+					fmt.Sprintf("ConfigMap does not contain %s", OpenStackClientConfigKey)))
+			}
+		}
+	}
+
+	secretPath := specPath.Child("openStackConfigSecret")
+	if r.Spec.OpenStackConfigSecret == "" {
+		allErrs = append(allErrs, field.Required(secretPath, fmt.Sprintf("name of a Secret providing the %s", OpenStackClientSecretKey)))
+	} else if c != nil {
+		secret := &corev1.Secret{}
+		err := c.Get(context.TODO(), types.NamespacedName{Name: r.Spec.OpenStackConfigSecret, Namespace: r.Namespace}, secret)
+		switch {
+		case apierrors.IsNotFound(err):
+			allErrs = append(allErrs, field.NotFound(secretPath, r.Spec.OpenStackConfigSecret))
+		case err != nil:
+			return nil, err
+		default:
+			if _, ok := secret.Data[OpenStackClientSecretKey]; !ok {
+				allErrs = append(allErrs, field.Invalid(secretPath, r.Spec.OpenStackConfigSecret,
+					fmt.Sprintf("Secret does not contain %s", OpenStackClientSecretKey)))
+			}
+		}
+	}
+	return allErrs, nil
+}
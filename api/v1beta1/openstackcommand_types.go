@@ -0,0 +1,98 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CommandPhase - state of the Job running the commands of an OpenStackCommand
+type CommandPhase string
+
+const (
+	// CommandPending - the Job did not start yet, e.g. the OpenStackClient does not exist
+	CommandPending CommandPhase = "Pending"
+	// CommandRunning - the commands are running or get retried
+	CommandRunning CommandPhase = "Running"
+	// CommandSucceeded - all commands exited with 0
+	CommandSucceeded CommandPhase = "Succeeded"
+	// CommandFailed - a command failed and the retries are exhausted
+	CommandFailed CommandPhase = "Failed"
+)
+
+// OpenStackCommandSpec defines the desired state of OpenStackCommand
+type OpenStackCommandSpec struct {
+	// name of the OpenStackClient in the same namespace, the commands run with its image and configuration
+	OpenStackClient string `json:"openStackClient"`
+	// shell command lines run in order, e.g. "openstack flavor create --ram 512 m1.tiny".
+	// The first failing command fails the attempt.
+	// +kubebuilder:validation:MinItems=1
+	Commands []string `json:"commands"`
+	// number of retries before the commands are considered failed, defaults to 3
+	// +kubebuilder:validation:Minimum=0
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+}
+
+// OpenStackCommandStatus defines the observed state of OpenStackCommand
+type OpenStackCommandStatus struct {
+	// Pending, Running, Succeeded or Failed
+	Phase CommandPhase `json:"phase,omitempty"`
+	// name of the Job running the commands
+	JobName string `json:"jobName,omitempty"`
+	// hash of the spec the Job got created for, a changed spec replaces the Job
+	CommandHash string `json:"commandHash,omitempty"`
+	// number of attempts started so far
+	Attempts int32 `json:"attempts,omitempty"`
+	// exit code of the last finished attempt
+	ExitCode *int32 `json:"exitCode,omitempty"`
+	// output of the last finished attempt, truncated to its last 4096 bytes
+	Output string `json:"output,omitempty"`
+	// human readable details on the phase
+	Message string `json:"message,omitempty"`
+	// when the Job got created
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// when the Job succeeded or failed
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Attempts",type="integer",JSONPath=".status.attempts"
+// +kubebuilder:printcolumn:name="Exit Code",type="integer",JSONPath=".status.exitCode"
+
+// OpenStackCommand is the Schema for the openstackcommands API
+type OpenStackCommand struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OpenStackCommandSpec   `json:"spec,omitempty"`
+	Status OpenStackCommandStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// OpenStackCommandList contains a list of OpenStackCommand
+type OpenStackCommandList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OpenStackCommand `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OpenStackCommand{}, &OpenStackCommandList{})
+}
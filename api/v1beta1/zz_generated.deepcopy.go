@@ -27,6 +27,39 @@ import (
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CinderSpec) DeepCopyInto(out *CinderSpec) {
 	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CinderAPIReplicas != nil {
+		in, out := &in.CinderAPIReplicas, &out.CinderAPIReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CinderSchedulerReplicas != nil {
+		in, out := &in.CinderSchedulerReplicas, &out.CinderSchedulerReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CinderBackupReplicas != nil {
+		in, out := &in.CinderBackupReplicas, &out.CinderBackupReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CinderVolumeReplicas != nil {
+		in, out := &in.CinderVolumeReplicas, &out.CinderVolumeReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.VolumeBackends != nil {
+		in, out := &in.VolumeBackends, &out.VolumeBackends
+		*out = make([]CinderVolumeBackendSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.CustomServiceConfigSpec = in.CustomServiceConfigSpec
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CinderSpec.
@@ -39,13 +72,80 @@ func (in *CinderSpec) DeepCopy() *CinderSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CinderVolumeBackendConfig) DeepCopyInto(out *CinderVolumeBackendConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CinderVolumeBackendConfig.
+func (in *CinderVolumeBackendConfig) DeepCopy() *CinderVolumeBackendConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CinderVolumeBackendConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CinderVolumeBackendSpec) DeepCopyInto(out *CinderVolumeBackendSpec) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	out.Config = in.Config
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CinderVolumeBackendSpec.
+func (in *CinderVolumeBackendSpec) DeepCopy() *CinderVolumeBackendSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CinderVolumeBackendSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapReference) DeepCopyInto(out *ConfigMapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapReference.
+func (in *ConfigMapReference) DeepCopy() *ConfigMapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControlPlane) DeepCopyInto(out *ControlPlane) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlane.
@@ -101,13 +201,16 @@ func (in *ControlPlaneList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControlPlaneSpec) DeepCopyInto(out *ControlPlaneSpec) {
 	*out = *in
-	out.Keystone = in.Keystone
-	out.Glance = in.Glance
-	out.Placement = in.Placement
-	out.Interconnect = in.Interconnect
-	out.Nova = in.Nova
-	out.Cinder = in.Cinder
-	out.Neutron = in.Neutron
+	in.MariaDB.DeepCopyInto(&out.MariaDB)
+	in.Keystone.DeepCopyInto(&out.Keystone)
+	in.Glance.DeepCopyInto(&out.Glance)
+	in.Placement.DeepCopyInto(&out.Placement)
+	in.Interconnect.DeepCopyInto(&out.Interconnect)
+	in.Nova.DeepCopyInto(&out.Nova)
+	in.Cinder.DeepCopyInto(&out.Cinder)
+	in.Neutron.DeepCopyInto(&out.Neutron)
+	out.Images = in.Images
+	out.Prune = in.Prune
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneSpec.
@@ -123,6 +226,27 @@ func (in *ControlPlaneSpec) DeepCopy() *ControlPlaneSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControlPlaneStatus) DeepCopyInto(out *ControlPlaneStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]ServiceStatus, len(*in))
+		copy(*out, *in)
+	}
+	in.CredentialsRotation.DeepCopyInto(&out.CredentialsRotation)
+	in.Rollout.DeepCopyInto(&out.Rollout)
+	in.Prune.DeepCopyInto(&out.Prune)
+	in.Teardown.DeepCopyInto(&out.Teardown)
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = make([]StorageStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneStatus.
@@ -135,9 +259,72 @@ func (in *ControlPlaneStatus) DeepCopy() *ControlPlaneStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsRotationStatus) DeepCopyInto(out *CredentialsRotationStatus) {
+	*out = *in
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsRotationStatus.
+func (in *CredentialsRotationStatus) DeepCopy() *CredentialsRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomServiceConfigSpec) DeepCopyInto(out *CustomServiceConfigSpec) {
+	*out = *in
+	out.CustomServiceConfigMap = in.CustomServiceConfigMap
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomServiceConfigSpec.
+func (in *CustomServiceConfigSpec) DeepCopy() *CustomServiceConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomServiceConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlanceBackendSpec) DeepCopyInto(out *GlanceBackendSpec) {
+	*out = *in
+	out.Storage = in.Storage
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlanceBackendSpec.
+func (in *GlanceBackendSpec) DeepCopy() *GlanceBackendSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GlanceBackendSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GlanceSpec) DeepCopyInto(out *GlanceSpec) {
 	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	out.Backend = in.Backend
+	out.CustomServiceConfigSpec = in.CustomServiceConfigSpec
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlanceSpec.
@@ -150,9 +337,34 @@ func (in *GlanceSpec) DeepCopy() *GlanceSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImagesSpec) DeepCopyInto(out *ImagesSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagesSpec.
+func (in *ImagesSpec) DeepCopy() *ImagesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImagesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InterconnectSpec) DeepCopyInto(out *InterconnectSpec) {
 	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterconnectSpec.
@@ -168,6 +380,17 @@ func (in *InterconnectSpec) DeepCopy() *InterconnectSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeystoneSpec) DeepCopyInto(out *KeystoneSpec) {
 	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	out.CustomServiceConfigSpec = in.CustomServiceConfigSpec
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneSpec.
@@ -180,9 +403,41 @@ func (in *KeystoneSpec) DeepCopy() *KeystoneSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MariaDBSpec) DeepCopyInto(out *MariaDBSpec) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	out.Storage = in.Storage
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MariaDBSpec.
+func (in *MariaDBSpec) DeepCopy() *MariaDBSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MariaDBSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NeutronSpec) DeepCopyInto(out *NeutronSpec) {
 	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	out.CustomServiceConfigSpec = in.CustomServiceConfigSpec
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NeutronSpec.
@@ -195,9 +450,77 @@ func (in *NeutronSpec) DeepCopy() *NeutronSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NovaCellSpec) DeepCopyInto(out *NovaCellSpec) {
+	*out = *in
+	if in.NovaConductorReplicas != nil {
+		in, out := &in.NovaConductorReplicas, &out.NovaConductorReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NovaMetadataReplicas != nil {
+		in, out := &in.NovaMetadataReplicas, &out.NovaMetadataReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NovaNoVNCProxyReplicas != nil {
+		in, out := &in.NovaNoVNCProxyReplicas, &out.NovaNoVNCProxyReplicas
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NovaCellSpec.
+func (in *NovaCellSpec) DeepCopy() *NovaCellSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NovaCellSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NovaSpec) DeepCopyInto(out *NovaSpec) {
 	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.NovaAPIReplicas != nil {
+		in, out := &in.NovaAPIReplicas, &out.NovaAPIReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NovaSchedulerReplicas != nil {
+		in, out := &in.NovaSchedulerReplicas, &out.NovaSchedulerReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NovaConductorReplicas != nil {
+		in, out := &in.NovaConductorReplicas, &out.NovaConductorReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NovaMetadataReplicas != nil {
+		in, out := &in.NovaMetadataReplicas, &out.NovaMetadataReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NovaNoVNCProxyReplicas != nil {
+		in, out := &in.NovaNoVNCProxyReplicas, &out.NovaNoVNCProxyReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Cells != nil {
+		in, out := &in.Cells, &out.Cells
+		*out = make([]NovaCellSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.CustomServiceConfigSpec = in.CustomServiceConfigSpec
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NovaSpec.
@@ -216,7 +539,7 @@ func (in *OpenStackClient) DeepCopyInto(out *OpenStackClient) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackClient.
@@ -287,6 +610,13 @@ func (in *OpenStackClientSpec) DeepCopy() *OpenStackClientSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OpenStackClientStatus) DeepCopyInto(out *OpenStackClientStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackClientStatus.
@@ -299,9 +629,132 @@ func (in *OpenStackClientStatus) DeepCopy() *OpenStackClientStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackCommand) DeepCopyInto(out *OpenStackCommand) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackCommand.
+func (in *OpenStackCommand) DeepCopy() *OpenStackCommand {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackCommand)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OpenStackCommand) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackCommandList) DeepCopyInto(out *OpenStackCommandList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OpenStackCommand, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackCommandList.
+func (in *OpenStackCommandList) DeepCopy() *OpenStackCommandList {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackCommandList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OpenStackCommandList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackCommandSpec) DeepCopyInto(out *OpenStackCommandSpec) {
+	*out = *in
+	if in.Commands != nil {
+		in, out := &in.Commands, &out.Commands
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackCommandSpec.
+func (in *OpenStackCommandSpec) DeepCopy() *OpenStackCommandSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackCommandSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackCommandStatus) DeepCopyInto(out *OpenStackCommandStatus) {
+	*out = *in
+	if in.ExitCode != nil {
+		in, out := &in.ExitCode, &out.ExitCode
+		*out = new(int32)
+		**out = **in
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackCommandStatus.
+func (in *OpenStackCommandStatus) DeepCopy() *OpenStackCommandStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackCommandStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlacementSpec) DeepCopyInto(out *PlacementSpec) {
 	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	out.CustomServiceConfigSpec = in.CustomServiceConfigSpec
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementSpec.
@@ -313,3 +766,132 @@ func (in *PlacementSpec) DeepCopy() *PlacementSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PruneSpec) DeepCopyInto(out *PruneSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PruneSpec.
+func (in *PruneSpec) DeepCopy() *PruneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PruneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PruneStatus) DeepCopyInto(out *PruneStatus) {
+	*out = *in
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PruneStatus.
+func (in *PruneStatus) DeepCopy() *PruneStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PruneStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStatus) DeepCopyInto(out *RolloutStatus) {
+	*out = *in
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.WaitingSince != nil {
+		in, out := &in.WaitingSince, &out.WaitingSince
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStatus.
+func (in *RolloutStatus) DeepCopy() *RolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceStatus) DeepCopyInto(out *ServiceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceStatus.
+func (in *ServiceStatus) DeepCopy() *ServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageSpec) DeepCopyInto(out *StorageSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageSpec.
+func (in *StorageSpec) DeepCopy() *StorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageStatus) DeepCopyInto(out *StorageStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageStatus.
+func (in *StorageStatus) DeepCopy() *StorageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeardownStatus) DeepCopyInto(out *TeardownStatus) {
+	*out = *in
+	if in.Deleting != nil {
+		in, out := &in.Deleting, &out.Deleting
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Deleted != nil {
+		in, out := &in.Deleted, &out.Deleted
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeardownStatus.
+func (in *TeardownStatus) DeepCopy() *TeardownStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TeardownStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,97 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/openstack-cluster-operator/pkg/util"
+)
+
+// customConfigHashAnnotation - annotation of the child CRs holding the hash of
+// their custom service configuration, set by the templates in bindata
+const customConfigHashAnnotation = "controlplane.openstack.org/custom-config-hash"
+
+// getCustomServiceConfigs resolves the oslo.config overrides of the services,
+// reading the snippets of the referenced ConfigMaps. It returns the snippet
+// passed to the child CR of each service and the hash it gets annotated with.
+// Disabled services are skipped, their ConfigMaps do not have to exist.
+func getCustomServiceConfigs(ctx context.Context, c client.Client, instance *controlplanev1beta1.ControlPlane) (map[string]string, map[string]string, error) {
+	configs := map[string]string{}
+	hashes := map[string]string{}
+	for service, spec := range instance.Spec.CustomServiceConfigs() {
+		if component := getServiceComponent(service); component != nil && !component.Enabled(instance) {
+			continue
+		}
+		config := spec.CustomServiceConfig
+		if ref := spec.CustomServiceConfigMap; ref.Name != "" {
+			configMap := &corev1.ConfigMap{}
+			err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: instance.Namespace}, configMap)
+			if err != nil {
+				if k8s_errors.IsNotFound(err) {
+					return nil, nil, fmt.Errorf("ConfigMap %s with the custom configuration of %s not found", ref.Name, service)
+				}
+				return nil, nil, err
+			}
+			var ok bool
+			if config, ok = configMap.Data[ref.Key]; !ok {
+				return nil, nil, fmt.Errorf("ConfigMap %s with the custom configuration of %s is missing %s", ref.Name, service, ref.Key)
+			}
+		}
+
+		hash, err := util.CalculateHash(config)
+		if err != nil {
+			return nil, nil, err
+		}
+		configs[service] = config
+		hashes[service] = hash
+	}
+	return configs, hashes, nil
+}
+
+// customConfigMapToRequests maps a ConfigMap to reconcile requests of the
+// ControlPlanes in its namespace referencing it as custom service configuration
+func (r *ControlPlaneReconciler) customConfigMapToRequests(obj handler.MapObject) []reconcile.Request {
+	controlPlanes := &controlplanev1beta1.ControlPlaneList{}
+	if err := r.Client.List(context.TODO(), controlPlanes, client.InNamespace(obj.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "Unable to list ControlPlanes", "Namespace", obj.Meta.GetNamespace())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for i := range controlPlanes.Items {
+		instance := &controlPlanes.Items[i]
+		for _, spec := range instance.Spec.CustomServiceConfigs() {
+			if spec.CustomServiceConfigMap.Name == obj.Meta.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
@@ -18,16 +18,25 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	uns "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
 	bindatautil "github.com/openstack-k8s-operators/openstack-cluster-operator/pkg/bindata_util"
@@ -40,8 +49,21 @@ const (
 	ownerUIDLabelSelector       = "controlplane.openstack.org/uid"
 	ownerNameSpaceLabelSelector = "controlplane.openstack.org/namespace"
 	ownerNameLabelSelector      = "controlplane.openstack.org/name"
+	serviceLabelSelector        = "controlplane.openstack.org/service"
+
+	// name of the MariaDB CR, its service is named alike
+	mariadbName = "mariadb"
+
+	// interval to re-check the child CRs while not all services are ready
+	statusRequeueInterval = 10 * time.Second
 )
 
+// pruneKinds - kinds always checked for orphaned objects, besides the kinds of the rendered objects
+var pruneKinds = []schema.GroupVersionKind{
+	{Version: "v1", Kind: "Secret"},
+	{Version: "v1", Kind: "ConfigMap"},
+}
+
 // ControlPlaneReconciler reconciles a ControlPlane object
 type ControlPlaneReconciler struct {
 	client.Client
@@ -51,6 +73,8 @@ type ControlPlaneReconciler struct {
 
 // +kubebuilder:rbac:groups=controlplane.openstack.org,resources=controlplanes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=controlplane.openstack.org,resources=controlplanes/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
 
 // Reconcile - controleplane api
 func (r *ControlPlaneReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
@@ -70,82 +94,122 @@ func (r *ControlPlaneReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 		// Error reading the object - requeue the request.
 		return ctrl.Result{}, err
 	}
-	setDefaults(instance)
 
-	data, err := getRenderData(context.TODO(), r.Client, instance)
-	if err != nil {
+	// Tear down the services in reverse dependency order before the ControlPlane goes away
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.teardown(context.TODO(), instance)
+	}
+	if added, err := r.ensureFinalizer(context.TODO(), instance); err != nil || added {
+		// the update of the finalizer triggers the next reconcile
 		return ctrl.Result{}, err
 	}
+	// the webhooks are not deployed everywhere, e.g. not by the CSV or when running locally
+	instance.Default()
+	if err := instance.Validate(); err != nil {
+		// reported via the Degraded condition, retrying does not help until the spec gets fixed
+		r.Log.Info("Invalid ControlPlane spec", "Error", err.Error())
+		_ = r.reportError(instance, err)
+		return ctrl.Result{}, nil
+	}
 
-	objs := []*uns.Unstructured{}
-
-	// Generate the MariaDB objects
-	manifests, err := bindatautil.RenderDir(filepath.Join(ManifestPath, "mariadb"), &data)
+	credentials, err := r.ensureCredentials(context.TODO(), instance)
 	if err != nil {
-		ctrl.Log.Error(err, "Failed to render mariadb manifests : %v")
-		return ctrl.Result{}, err
+		return ctrl.Result{}, r.reportError(instance, err)
+	}
+	if err := r.applyUserCredentials(context.TODO(), instance, credentials); err != nil {
+		return ctrl.Result{}, r.reportError(instance, err)
 	}
-	objs = append(objs, manifests...)
 
-	// Generate the AMQ Interconnect objects
-	manifests, err = bindatautil.RenderDir(filepath.Join(ManifestPath, "interconnect"), &data)
+	data, err := getRenderData(context.TODO(), r.Client, instance, credentials)
 	if err != nil {
-		ctrl.Log.Error(err, "Failed to render interconnect manifests : %v")
-		return ctrl.Result{}, err
+		return ctrl.Result{}, r.reportError(instance, err)
 	}
-	objs = append(objs, manifests...)
 
-	// Generate the Keystone objects
-	manifests, err = bindatautil.RenderDir(filepath.Join(ManifestPath, "keystone"), &data)
+	waves, err := serviceWaves()
 	if err != nil {
-		ctrl.Log.Error(err, "Failed to render keystone manifests : %v")
-		return ctrl.Result{}, err
+		return ctrl.Result{}, r.reportError(instance, err)
 	}
-	objs = append(objs, manifests...)
 
-	// Generate the Glance objects
-	manifests, err = bindatautil.RenderDir(filepath.Join(ManifestPath, "glance"), &data)
-	if err != nil {
-		ctrl.Log.Error(err, "Failed to render glance manifests : %v")
-		return ctrl.Result{}, err
+	// Generate the objects of all services, the complete set is required
+	// to find the orphaned objects once all waves got applied
+	rendered := map[string][]*uns.Unstructured{}
+	desired := []*uns.Unstructured{}
+	for _, component := range ServiceComponents() {
+		manifests, err := r.renderService(context.TODO(), instance, component, &data)
+		if err != nil {
+			r.Log.Error(err, "Failed to render manifests", "Service", component.Name())
+			return ctrl.Result{}, r.reportError(instance, err)
+		}
+		rendered[component.Name()] = manifests
+		desired = append(desired, manifests...)
 	}
-	objs = append(objs, manifests...)
 
-	// Generate the Placement objects
-	manifests, err = bindatautil.RenderDir(filepath.Join(ManifestPath, "placement"), &data)
-	if err != nil {
-		ctrl.Log.Error(err, "Failed to render placement manifests : %v")
-		return ctrl.Result{}, err
+	// Roll out the services wave by wave, each wave only gets applied once
+	// all services of the previous waves report ready
+	for i, wave := range waves {
+		objs := []*uns.Unstructured{}
+		for _, component := range wave {
+			objs = append(objs, rendered[component.Name()]...)
+		}
+
+		if err := r.applyObjects(context.TODO(), instance, objs); err != nil {
+			return ctrl.Result{}, r.reportError(instance, err)
+		}
+		if err := r.reconcileStorage(context.TODO(), instance, wave); err != nil {
+			return ctrl.Result{}, r.reportError(instance, err)
+		}
+
+		notReady := r.notReadyServices(context.TODO(), instance, wave)
+		setRolloutWave(instance, waves, i, len(notReady) > 0)
+		if len(notReady) > 0 {
+			r.Log.Info("Waiting for services", "Wave", i+1, "Services", notReady)
+			if _, err := r.updateStatus(context.TODO(), instance, nil); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: rolloutBackoff(instance)}, nil
+		}
 	}
-	objs = append(objs, manifests...)
 
-	// Generate the Neutron objects
-	manifests, err = bindatautil.RenderDir(filepath.Join(ManifestPath, "neutron"), &data)
-	if err != nil {
-		ctrl.Log.Error(err, "Failed to render neutron manifests : %v")
-		return ctrl.Result{}, err
+	if err := r.pruneObjects(context.TODO(), instance, desired); err != nil {
+		return ctrl.Result{}, r.reportError(instance, err)
 	}
-	objs = append(objs, manifests...)
 
-	// Generate the Cinder objects
-	// TODO: how to handle adding additional cinder-volume services using openstack-cluster-operator
-	manifests, err = bindatautil.RenderDir(filepath.Join(ManifestPath, "cinder"), &data)
+	// the rotated credentials got applied to the services of all waves
+	setRotationStatus(instance, nil)
+	ready, err := r.updateStatus(context.TODO(), instance, nil)
 	if err != nil {
-		ctrl.Log.Error(err, "Failed to render cinder manifests : %v")
 		return ctrl.Result{}, err
 	}
-	objs = append(objs, manifests...)
+	if !ready || !storageSettled(instance) {
+		// poll for the child CRs of kinds which were not installed on startup,
+		// and for the progress of PVC expansions
+		return ctrl.Result{RequeueAfter: statusRequeueInterval}, nil
+	}
 
-	// Generate the Nova objects
-	// TODO: how to handle adding additional cells using openstack-cluster-operator
-	manifests, err = bindatautil.RenderDir(filepath.Join(ManifestPath, "nova"), &data)
-	if err != nil {
-		ctrl.Log.Error(err, "Failed to render nova manifests : %v")
-		return ctrl.Result{}, err
+	return ctrl.Result{}, nil
+}
+
+// reportError records a failed reconcile in the ControlPlane status and returns the original error
+func (r *ControlPlaneReconciler) reportError(instance *controlplanev1beta1.ControlPlane, err error) error {
+	setRotationStatus(instance, err)
+	if _, statusErr := r.updateStatus(context.TODO(), instance, err); statusErr != nil {
+		r.Log.Error(statusErr, "Failed to update ControlPlane status")
+	}
+	return err
+}
+
+// applyObjects points the images of the objects to the configured registry
+// mirror, labels them with the owning ControlPlane and applies them
+func (r *ControlPlaneReconciler) applyObjects(ctx context.Context, instance *controlplanev1beta1.ControlPlane, objs []*uns.Unstructured) error {
+	imageRewrite := bindatautil.ImageRewrite{
+		Prefix: instance.Spec.Images.RegistryPrefix,
+		Tag:    instance.Spec.Images.Tag,
+	}
+	if err := bindatautil.TransformObjects(objs, bindatautil.RewriteImages(imageRewrite)); err != nil {
+		r.Log.Error(err, "Failed to rewrite images")
+		return err
 	}
-	objs = append(objs, manifests...)
 
-	// Apply the objects to the cluster
 	oref := metav1.NewControllerRef(instance, instance.GroupVersionKind())
 	labelSelector := map[string]string{
 		ownerUIDLabelSelector:       string(instance.UID),
@@ -159,48 +223,187 @@ func (r *ControlPlaneReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 		}
 		// merge owner ref label into labels on the objects
 		obj.SetLabels(labels.Merge(obj.GetLabels(), labelSelector))
-		objs = append(objs, obj)
 
-		if err := bindatautil.ApplyObject(context.TODO(), r.Client, obj); err != nil {
-			ctrl.Log.Error(err, "Failed to apply objects")
-			return ctrl.Result{}, err
+		if err := bindatautil.ApplyObject(ctx, r.Client, obj); err != nil {
+			r.Log.Error(err, "Failed to apply objects")
+			return err
 		}
 	}
+	return nil
+}
 
-	return ctrl.Result{}, nil
+// pruneObjects deletes the objects labelled for this ControlPlane which are
+// no longer rendered, e.g. as their template got removed or renamed. Only the
+// managedKinds and the kinds of the rendered objects in the namespace of the
+// ControlPlane are looked at, which is where all its objects get rendered.
+func (r *ControlPlaneReconciler) pruneObjects(ctx context.Context, instance *controlplanev1beta1.ControlPlane, desired []*uns.Unstructured) error {
+	gvks := managedKinds()
+	seen := map[schema.GroupVersionKind]bool{}
+	for _, gvk := range gvks {
+		seen[gvk] = true
+	}
+	for _, obj := range desired {
+		if gvk := obj.GroupVersionKind(); !seen[gvk] {
+			seen[gvk] = true
+			gvks = append(gvks, gvk)
+		}
+	}
+
+	selector := map[string]string{ownerUIDLabelSelector: string(instance.UID)}
+	pruned, err := bindatautil.PruneObjects(ctx, r.Client, gvks, selector, desired, bindatautil.PruneOptions{
+		DryRun:    instance.Spec.Prune.DryRun,
+		Namespace: instance.Namespace,
+	})
+
+	objects := []string{}
+	for _, obj := range pruned {
+		objects = append(objects, fmt.Sprintf("%s/%s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName()))
+	}
+	if len(objects) > 0 {
+		r.Log.Info("Pruned orphaned objects", "DryRun", instance.Spec.Prune.DryRun, "Objects", objects)
+	}
+	instance.Status.Prune = controlplanev1beta1.PruneStatus{
+		DryRun:  instance.Spec.Prune.DryRun,
+		Objects: objects,
+	}
+	return err
+}
+
+// renderService renders the manifests of a service and labels them with the
+// service name. The objects of disabled services are not returned, instead
+// the ones applied earlier get deleted.
+func (r *ControlPlaneReconciler) renderService(ctx context.Context, instance *controlplanev1beta1.ControlPlane, component ServiceComponent, data *bindatautil.RenderData) ([]*uns.Unstructured, error) {
+	manifests, err := bindatautil.RenderDir(filepath.Join(ManifestPath, component.ManifestDir()), data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !component.Enabled(instance) {
+		return nil, r.deleteServiceObjects(ctx, instance, component.Name(), manifests)
+	}
+
+	for _, obj := range manifests {
+		obj.SetLabels(labels.Merge(obj.GetLabels(), map[string]string{serviceLabelSelector: component.Name()}))
+	}
+	return manifests, nil
 }
 
-// SetupWithManager -
+// deleteServiceObjects deletes the objects of a disabled service which got applied for this ControlPlane
+func (r *ControlPlaneReconciler) deleteServiceObjects(ctx context.Context, instance *controlplanev1beta1.ControlPlane, service string, manifests []*uns.Unstructured) error {
+	for _, obj := range manifests {
+		existing := &uns.Unstructured{}
+		existing.SetGroupVersionKind(obj.GroupVersionKind())
+		err := r.Client.Get(ctx, types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}, existing)
+		if err != nil {
+			if k8s_errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return err
+		}
+		// only delete what was created for this ControlPlane
+		if existing.GetLabels()[ownerUIDLabelSelector] != string(instance.UID) {
+			continue
+		}
+
+		r.Log.Info("Deleting object of disabled service", "Service", service, "Kind", existing.GetKind(), "Name", existing.GetName())
+		if err := r.Client.Delete(ctx, existing); err != nil && !k8s_errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetupWithManager - besides the ControlPlane the managed kinds get watched,
+// so changes to the child CRs, Secrets and referenced ConfigMaps trigger a
+// reconcile of their owner
 func (r *ControlPlaneReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&controlplanev1beta1.ControlPlane{}).
-		Complete(r)
+		// the credentials store is owned but not labelled
+		Owns(&corev1.Secret{})
+
+	ownerHandler := &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(ownerLabelsToRequests)}
+	for _, gvk := range managedKinds() {
+		// child CRDs which are not installed yet can not be watched, their
+		// CRs are polled as long as the services are not ready
+		if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			r.Log.Info("Not watching kind, it is unknown to the apiserver", "Kind", gvk.String())
+			continue
+		}
+
+		obj, err := mgr.GetScheme().New(gvk)
+		if err != nil {
+			u := &uns.Unstructured{}
+			u.SetGroupVersionKind(gvk)
+			obj = u
+		}
+		b = b.Watches(&source.Kind{Type: obj}, ownerHandler)
+	}
+
+	// ConfigMaps providing custom service configuration are not owned
+	configHandler := &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.customConfigMapToRequests)}
+	b = b.Watches(&source.Kind{Type: &corev1.ConfigMap{}}, configHandler)
+
+	return b.Complete(r)
+}
+
+// ownerLabelsToRequests maps an object to a reconcile request of the
+// ControlPlane it got applied for, based on the owner labels
+func ownerLabelsToRequests(obj handler.MapObject) []reconcile.Request {
+	objLabels := obj.Meta.GetLabels()
+	name := objLabels[ownerNameLabelSelector]
+	namespace := objLabels[ownerNameSpaceLabelSelector]
+	if name == "" || namespace == "" {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}},
+	}
+}
+
+// getDatabaseHostname - host of the MariaDB of the ControlPlane, or of the
+// external database used when MariaDB is disabled
+func getDatabaseHostname(instance *controlplanev1beta1.ControlPlane) string {
+	if instance.Spec.MariaDB.ExternalHostname != "" {
+		return instance.Spec.MariaDB.ExternalHostname
+	}
+	return mariadbName
 }
 
-func getRenderData(ctx context.Context, client client.Client, instance *controlplanev1beta1.ControlPlane) (bindatautil.RenderData, error) {
+func getRenderData(ctx context.Context, client client.Client, instance *controlplanev1beta1.ControlPlane, credentials map[string]string) (bindatautil.RenderData, error) {
 	data := bindatautil.MakeRenderData()
-	data.Data["KeystoneReplicas"] = instance.Spec.Keystone.Replicas
-	data.Data["GlanceReplicas"] = instance.Spec.Glance.Replicas
-	data.Data["PlacementReplicas"] = instance.Spec.Placement.Replicas
-	data.Data["InterconnectReplicas"] = instance.Spec.Interconnect.Replicas
-	data.Data["NovaAPIReplicas"] = instance.Spec.Nova.NovaAPIReplicas
-	data.Data["NovaConductorReplicas"] = instance.Spec.Nova.NovaConductorReplicas
-	data.Data["NovaMetadataReplicas"] = instance.Spec.Nova.NovaMetadataReplicas
-	data.Data["NovaNoVNCProxyReplicas"] = instance.Spec.Nova.NovaNoVNCProxyReplicas
-	data.Data["NovaSchedulerReplicas"] = instance.Spec.Nova.NovaSchedulerReplicas
-	data.Data["CinderAPIReplicas"] = instance.Spec.Cinder.CinderAPIReplicas
-	data.Data["CinderBackupReplicas"] = instance.Spec.Cinder.CinderBackupReplicas
-	data.Data["CinderSchedulerReplicas"] = instance.Spec.Cinder.CinderSchedulerReplicas
-	data.Data["CinderVolumeReplicas"] = instance.Spec.Cinder.CinderVolumeReplicas
-	data.Data["NeutronAPIReplicas"] = instance.Spec.Neutron.Replicas
 	data.Data["Namespace"] = instance.Namespace
-	data.Data["StorageClass"] = instance.Spec.StorageClass
-	return data, nil
-}
+	data.Data["Passwords"] = credentials
+	data.Data["Images"] = getImages(instance)
+	data.Data["DatabaseHostname"] = getDatabaseHostname(instance)
+
+	// messaging, all transport urls are derived from the Interconnect endpoint
+	endpoint := getMessagingEndpoint(instance)
+	defaultUser, cellUsers := getMessagingUsers(instance, credentials)
+	messagingUsers := map[string]string{defaultUser.Name: defaultUser.Password}
+	cellTransportURLs := map[string]string{}
+	for cell, user := range cellUsers {
+		messagingUsers[user.Name] = user.Password
+		cellTransportURLs[cell] = endpoint.transportURL(user)
+	}
+	data.Data["InterconnectName"] = interconnectName
+	data.Data["MessagingUsers"] = messagingUsers
+	data.Data["TransportURL"] = endpoint.transportURL(defaultUser)
+	data.Data["CellTransportURLs"] = cellTransportURLs
 
-func setDefaults(instance *controlplanev1beta1.ControlPlane) {
-	// required to be greated than 0 by the interconnect operator
-	if instance.Spec.Interconnect.Replicas < 1 {
-		instance.Spec.Interconnect.Replicas = 1
+	customConfigs, configHashes, err := getCustomServiceConfigs(ctx, client, instance)
+	if err != nil {
+		return data, err
 	}
+	data.Data["CustomServiceConfigs"] = customConfigs
+	data.Data["ConfigHashes"] = configHashes
+
+	// service specific data, also for disabled services as their manifests
+	// get rendered to find the objects to delete
+	for _, component := range ServiceComponents() {
+		if err := component.RenderData(instance, &data); err != nil {
+			return data, err
+		}
+	}
+	return data, nil
 }
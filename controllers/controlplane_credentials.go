@@ -0,0 +1,226 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
+	util "github.com/openstack-k8s-operators/openstack-cluster-operator/pkg/util"
+)
+
+const (
+	// length of the generated service passwords
+	passwordLength = 32
+
+	// annotation on the credentials store recording the rotationGeneration its passwords were generated for
+	rotationGenerationAnnotation = "controlplane.openstack.org/rotation-generation"
+)
+
+// getCredentialKeys - keys of the credentials store of the ControlPlane, one per
+// credential of the registered services
+func getCredentialKeys(instance *controlplanev1beta1.ControlPlane) []string {
+	keys := []string{}
+	for _, component := range ServiceComponents() {
+		credentials := component.Credentials(instance)
+		secretKeys := []string{}
+		for key := range credentials {
+			secretKeys = append(secretKeys, key)
+		}
+		sort.Strings(secretKeys)
+		for _, key := range secretKeys {
+			keys = append(keys, credentials[key])
+		}
+	}
+	return keys
+}
+
+// nonRotatedCredentials - credentials which are kept on rotation. The
+// MariaDB root password is only consumed when the database gets initialized,
+// changing it afterwards would lock the mariadb operator out.
+var nonRotatedCredentials = map[string]bool{
+	"MariaDBRootPassword": true,
+}
+
+// credentialsSecretName - name of the Secret holding the generated passwords of a ControlPlane
+func credentialsSecretName(instance *controlplanev1beta1.ControlPlane) string {
+	return fmt.Sprintf("%s-credentials", instance.Name)
+}
+
+// ensureCredentials makes sure the credentials store of the ControlPlane
+// exists and holds a password for each of its credential keys. Missing
+// passwords get generated, existing ones are only changed when a rotation
+// was requested by bumping spec.rotationGeneration. The passwords of a newly
+// created store are generated for the current rotationGeneration, which gets
+// recorded in the status without reporting a rotation.
+func (r *ControlPlaneReconciler) ensureCredentials(ctx context.Context, instance *controlplanev1beta1.ControlPlane) (map[string]string, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      credentialsSecretName(instance),
+			Namespace: instance.Namespace,
+		},
+	}
+
+	keys := getCredentialKeys(instance)
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		rotate := secret.CreationTimestamp.IsZero() || storeRotationGeneration(secret) < instance.Spec.RotationGeneration
+		for _, key := range keys {
+			if len(secret.Data[key]) > 0 && !(rotate && !nonRotatedCredentials[key]) {
+				continue
+			}
+			password, err := util.GeneratePassword(passwordLength)
+			if err != nil {
+				return err
+			}
+			secret.Data[key] = []byte(password)
+		}
+		if rotate {
+			if secret.Annotations == nil {
+				secret.Annotations = map[string]string{}
+			}
+			secret.Annotations[rotationGenerationAnnotation] = strconv.FormatInt(instance.Spec.RotationGeneration, 10)
+		}
+		return controllerutil.SetControllerReference(instance, secret, r.Scheme)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if op != controllerutil.OperationResultNone {
+		r.Log.Info("Credentials store reconciled", "Secret", secret.Name, "Operation", op)
+	}
+	if op == controllerutil.OperationResultCreated {
+		instance.Status.CredentialsRotation.RotationGeneration = instance.Spec.RotationGeneration
+	}
+
+	credentials := map[string]string{}
+	for _, key := range keys {
+		credentials[key] = string(secret.Data[key])
+	}
+	return credentials, nil
+}
+
+// storeRotationGeneration - rotationGeneration the passwords of the credentials store were generated for
+func storeRotationGeneration(secret *corev1.Secret) int64 {
+	generation, err := strconv.ParseInt(secret.Annotations[rotationGenerationAnnotation], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return generation
+}
+
+// setRotationStatus records the outcome of a pending credentials rotation,
+// which is one requested after the credentials store got created. A failed
+// rotation is retried with the next reconcile, the passwords already rotated
+// in the credentials store are kept.
+func setRotationStatus(instance *controlplanev1beta1.ControlPlane, reconcileErr error) {
+	if instance.Spec.RotationGeneration <= instance.Status.CredentialsRotation.RotationGeneration {
+		return
+	}
+
+	now := metav1.Now()
+	instance.Status.CredentialsRotation.LastRotationTime = &now
+	if reconcileErr != nil {
+		instance.Status.CredentialsRotation.Result = controlplanev1beta1.RotationFailed
+		instance.Status.CredentialsRotation.Message = reconcileErr.Error()
+		return
+	}
+	instance.Status.CredentialsRotation.RotationGeneration = instance.Spec.RotationGeneration
+	instance.Status.CredentialsRotation.Result = controlplanev1beta1.RotationSucceeded
+	instance.Status.CredentialsRotation.Message = ""
+}
+
+// applyUserCredentials replaces generated credentials with the values of the
+// user supplied Secrets referenced in the ControlPlane spec. The outcome of
+// the validation of those Secrets is recorded in the CredentialsValid condition.
+func (r *ControlPlaneReconciler) applyUserCredentials(ctx context.Context, instance *controlplanev1beta1.ControlPlane, credentials map[string]string) error {
+	overrides := map[string]string{}
+	problems := []string{}
+
+	for _, component := range ServiceComponents() {
+		service := component.Name()
+		secretName := component.UserSecret(instance)
+		if secretName == "" {
+			continue
+		}
+
+		secret := &corev1.Secret{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: instance.Namespace}, secret)
+		if err != nil {
+			if k8s_errors.IsNotFound(err) {
+				problems = append(problems, fmt.Sprintf("%s: secret %s not found", service, secretName))
+				continue
+			}
+			return err
+		}
+
+		secretKeys := component.Credentials(instance)
+		keys := []string{}
+		for key := range secretKeys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		missing := []string{}
+		for _, key := range keys {
+			val, ok := secret.Data[key]
+			if !ok || len(val) == 0 {
+				missing = append(missing, key)
+				continue
+			}
+			overrides[secretKeys[key]] = string(val)
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("%s: secret %s is missing %s", service, secretName, strings.Join(missing, ", ")))
+		}
+	}
+
+	if len(problems) > 0 {
+		msg := strings.Join(problems, "; ")
+		controlplanev1beta1.SetCondition(&instance.Status.Conditions, controlplanev1beta1.Condition{
+			Type:               controlplanev1beta1.ConditionCredentialsValid,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: instance.Generation,
+			Reason:             "InvalidSecrets",
+			Message:            msg,
+		})
+		return fmt.Errorf("invalid credential secrets: %s", msg)
+	}
+
+	for key, val := range overrides {
+		credentials[key] = val
+	}
+	controlplanev1beta1.SetCondition(&instance.Status.Conditions, controlplanev1beta1.Condition{
+		Type:               controlplanev1beta1.ConditionCredentialsValid,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		Reason:             "AsExpected",
+	})
+	return nil
+}
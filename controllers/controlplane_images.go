@@ -0,0 +1,109 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"os"
+
+	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/openstack-cluster-operator/pkg/operator"
+)
+
+// ServiceImage - a container image deployed for a service
+type ServiceImage struct {
+	// Name - key of the image in the template data, e.g. GlanceAPI
+	Name string
+	// Field - json field name of the override in the images of the ControlPlane spec
+	Field string
+	// RelatedImage - name of the related image in the CSV, OLM passes the
+	// (mirrored) image as RELATED_IMAGE_<NAME> env var to the operator
+	RelatedImage string
+	// Default - image used unless overridden by the related image or the spec
+	Default string
+}
+
+// openStackClientImage - image of the OpenStackClient pods, which are not deployed by a service
+var openStackClientImage = ServiceImage{
+	Name:         "OpenStackClient",
+	RelatedImage: "openstackclient",
+	Default:      "quay.io/openstack-k8s-operators/tripleo-deploy:latest",
+}
+
+// relatedImages - images set in the RELATED_IMAGE_<NAME> env vars, by related image name
+var relatedImages = map[string]string{}
+
+// LoadRelatedImages replaces the default images with the ones set in the
+// RELATED_IMAGE_<NAME> environment variables. It is meant to be called once
+// on startup and returns the env vars which were used.
+func LoadRelatedImages() []string {
+	images := []ServiceImage{openStackClientImage}
+	for _, component := range ServiceComponents() {
+		images = append(images, component.Images()...)
+	}
+
+	used := []string{}
+	for _, image := range images {
+		envVar := operator.RelatedImageEnvVar(image.RelatedImage)
+		if related, found := os.LookupEnv(envVar); found && related != "" {
+			relatedImages[image.RelatedImage] = related
+			used = append(used, envVar)
+		}
+	}
+	return used
+}
+
+// defaultImage - the related image if set, the default shipped with the operator otherwise
+func defaultImage(image ServiceImage) string {
+	if related := relatedImages[image.RelatedImage]; related != "" {
+		return related
+	}
+	return image.Default
+}
+
+// DefaultImages - the default images of the registered services as ImagesSpec, used by the defaulting webhook
+func DefaultImages() controlplanev1beta1.ImagesSpec {
+	images := controlplanev1beta1.ImagesSpec{}
+	fields := images.Images()
+	for _, component := range ServiceComponents() {
+		for _, image := range component.Images() {
+			if field, ok := fields[image.Field]; ok {
+				*field = defaultImage(image)
+			}
+		}
+	}
+	return images
+}
+
+// DefaultOpenStackClientImage - the default image of the OpenStackClient pod, used by the defaulting webhook
+func DefaultOpenStackClientImage() string {
+	return defaultImage(openStackClientImage)
+}
+
+// getImages - the container image of each component, the spec overrides win over the defaults
+func getImages(instance *controlplanev1beta1.ControlPlane) map[string]string {
+	overrides := instance.Spec.Images.Images()
+	images := map[string]string{}
+	for _, component := range ServiceComponents() {
+		for _, image := range component.Images() {
+			images[image.Name] = defaultImage(image)
+			if override, ok := overrides[image.Field]; ok && *override != "" {
+				images[image.Name] = *override
+			}
+		}
+	}
+	return images
+}
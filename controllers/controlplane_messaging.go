@@ -0,0 +1,122 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
+)
+
+const (
+	// name of the Interconnect CR, its service is named alike
+	interconnectName = "amq-interconnect"
+	// AMQP port of the Interconnect service
+	interconnectPort = 5672
+	// messaging user of the top level services
+	defaultMessagingUser = "osp"
+)
+
+// messagingEndpoint - AMQP endpoint provided by the Interconnect of a ControlPlane
+type messagingEndpoint struct {
+	Host string
+	Port int
+}
+
+// messagingUser - credentials and vhost of an Interconnect user
+type messagingUser struct {
+	Name     string
+	Password string
+	Vhost    string
+}
+
+// messagingCell - a nova cell with its own messaging user and vhost
+type messagingCell struct {
+	Name  string
+	Vhost string
+	// key in the credentials store holding the password of the cell user
+	CredentialKey string
+}
+
+// getNovaCells - cells of the ControlPlane which get a messaging user of their own
+func getNovaCells(instance *controlplanev1beta1.ControlPlane) []messagingCell {
+	cells := []messagingCell{}
+	for _, cell := range instance.Spec.Nova.Cells {
+		cells = append(cells, messagingCell{
+			Name:          cell.Name,
+			Vhost:         cell.MessagingVhost,
+			CredentialKey: cellTransportPasswordKey(cell.Name),
+		})
+	}
+	return cells
+}
+
+// cellTransportPasswordKey - key of the messaging password of a cell in the credentials store, e.g. Cell1TransportPassword
+func cellTransportPasswordKey(cell string) string {
+	return fmt.Sprintf("%sTransportPassword", strings.Title(cell))
+}
+
+// getMessagingEndpoint - the AMQP endpoint of the Interconnect in the namespace of the
+// ControlPlane, or the external endpoint used when Interconnect is disabled
+func getMessagingEndpoint(instance *controlplanev1beta1.ControlPlane) messagingEndpoint {
+	if host := instance.Spec.Interconnect.ExternalHost; host != "" {
+		endpoint := messagingEndpoint{
+			Host: host,
+			Port: instance.Spec.Interconnect.ExternalPort,
+		}
+		if endpoint.Port == 0 {
+			endpoint.Port = interconnectPort
+		}
+		return endpoint
+	}
+	return messagingEndpoint{
+		Host: fmt.Sprintf("%s.%s.svc", interconnectName, instance.Namespace),
+		Port: interconnectPort,
+	}
+}
+
+// transportURL - oslo.messaging transport url for user connecting to the endpoint
+func (e messagingEndpoint) transportURL(user messagingUser) string {
+	u := url.URL{
+		Scheme: "amqp",
+		User:   url.UserPassword(user.Name, user.Password),
+		Host:   fmt.Sprintf("%s:%d", e.Host, e.Port),
+	}
+	if user.Vhost != "" {
+		u.Path = "/" + user.Vhost
+	}
+	return u.String()
+}
+
+// getMessagingUsers - the default messaging user and one user per cell, by cell name
+func getMessagingUsers(instance *controlplanev1beta1.ControlPlane, credentials map[string]string) (messagingUser, map[string]messagingUser) {
+	defaultUser := messagingUser{
+		Name:     defaultMessagingUser,
+		Password: credentials["TransportPassword"],
+	}
+	cellUsers := map[string]messagingUser{}
+	for _, cell := range getNovaCells(instance) {
+		cellUsers[cell.Name] = messagingUser{
+			Name:     cell.Name,
+			Password: credentials[cell.CredentialKey],
+			Vhost:    cell.Vhost,
+		}
+	}
+	return defaultUser, cellUsers
+}
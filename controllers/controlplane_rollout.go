@@ -0,0 +1,136 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
+)
+
+const (
+	// first requeue interval while waiting for the services of a wave
+	minRolloutRequeue = 5 * time.Second
+	// upper bound of the requeue interval while waiting for the services of a wave
+	maxRolloutRequeue = 2 * time.Minute
+)
+
+// serviceWaves groups the registered services into waves. A service is in
+// the wave after the last of its dependencies, services without dependencies
+// form the first wave. Within a wave the registration order is kept.
+func serviceWaves() ([][]ServiceComponent, error) {
+	waveOf := map[string]int{}
+
+	var visit func(component ServiceComponent, path []string) (int, error)
+	visit = func(component ServiceComponent, path []string) (int, error) {
+		name := component.Name()
+		if wave, ok := waveOf[name]; ok {
+			return wave, nil
+		}
+		for _, p := range path {
+			if p == name {
+				return 0, fmt.Errorf("dependency cycle between services: %s", strings.Join(append(path, name), " -> "))
+			}
+		}
+
+		wave := 0
+		for _, dep := range component.Dependencies() {
+			depComponent := getServiceComponent(dep)
+			if depComponent == nil {
+				return 0, fmt.Errorf("service %s depends on unknown service %s", name, dep)
+			}
+			depWave, err := visit(depComponent, append(path, name))
+			if err != nil {
+				return 0, err
+			}
+			if depWave+1 > wave {
+				wave = depWave + 1
+			}
+		}
+		waveOf[name] = wave
+		return wave, nil
+	}
+
+	waves := [][]ServiceComponent{}
+	for _, component := range serviceComponents {
+		wave, err := visit(component, nil)
+		if err != nil {
+			return nil, err
+		}
+		for len(waves) <= wave {
+			waves = append(waves, []ServiceComponent{})
+		}
+		waves[wave] = append(waves[wave], component)
+	}
+	return waves, nil
+}
+
+// notReadyServices - names of the enabled services of a wave which do not report ready yet
+func (r *ControlPlaneReconciler) notReadyServices(ctx context.Context, instance *controlplanev1beta1.ControlPlane, wave []ServiceComponent) []string {
+	notReady := []string{}
+	for _, component := range wave {
+		if !component.Enabled(instance) {
+			continue
+		}
+		if !component.Status(ctx, r.Client, instance).Ready {
+			notReady = append(notReady, component.Name())
+		}
+	}
+	return notReady
+}
+
+// setRolloutWave records the wave the rollout is at. waiting is true as long
+// as the services of the wave are not ready, the time the rollout started
+// waiting for them is kept until the next wave is reached.
+func setRolloutWave(instance *controlplanev1beta1.ControlPlane, waves [][]ServiceComponent, current int, waiting bool) {
+	rollout := &instance.Status.Rollout
+	if rollout.CurrentWave != int32(current+1) || !waiting {
+		rollout.WaitingSince = nil
+	}
+	rollout.CurrentWave = int32(current + 1)
+	rollout.Waves = int32(len(waves))
+	rollout.Services = []string{}
+	for _, component := range waves[current] {
+		rollout.Services = append(rollout.Services, component.Name())
+	}
+	if waiting && rollout.WaitingSince == nil {
+		now := metav1.Now()
+		rollout.WaitingSince = &now
+	}
+}
+
+// rolloutBackoff - requeue interval while waiting for a wave, it grows with
+// the time already spent waiting so slow services are not polled needlessly
+func rolloutBackoff(instance *controlplanev1beta1.ControlPlane) time.Duration {
+	since := instance.Status.Rollout.WaitingSince
+	if since == nil {
+		return minRolloutRequeue
+	}
+	backoff := time.Since(since.Time)
+	if backoff < minRolloutRequeue {
+		return minRolloutRequeue
+	}
+	if backoff > maxRolloutRequeue {
+		return maxRolloutRequeue
+	}
+	return backoff
+}
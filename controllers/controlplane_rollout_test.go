@@ -0,0 +1,127 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestServiceWaves(t *testing.T) {
+	type service struct {
+		name         string
+		dependencies []string
+	}
+
+	tests := []struct {
+		name     string
+		services []service
+		want     [][]string
+		wantErr  string
+	}{
+		{
+			name: "no dependencies",
+			services: []service{
+				{name: "mariadb"},
+				{name: "interconnect"},
+			},
+			want: [][]string{{"mariadb", "interconnect"}},
+		},
+		{
+			name: "chain",
+			services: []service{
+				{name: "glance", dependencies: []string{"keystone"}},
+				{name: "keystone", dependencies: []string{"mariadb"}},
+				{name: "mariadb"},
+			},
+			want: [][]string{{"mariadb"}, {"keystone"}, {"glance"}},
+		},
+		{
+			name: "wave after the last dependency",
+			services: []service{
+				{name: "mariadb"},
+				{name: "interconnect"},
+				{name: "keystone", dependencies: []string{"mariadb"}},
+				{name: "glance", dependencies: []string{"mariadb", "keystone"}},
+				{name: "cinder", dependencies: []string{"interconnect", "glance"}},
+				{name: "placement", dependencies: []string{"keystone"}},
+			},
+			want: [][]string{{"mariadb", "interconnect"}, {"keystone"}, {"glance", "placement"}, {"cinder"}},
+		},
+		{
+			name: "unknown dependency",
+			services: []service{
+				{name: "mariadb"},
+				{name: "keystone", dependencies: []string{"mariadb", "memcached"}},
+			},
+			wantErr: "service keystone depends on unknown service memcached",
+		},
+		{
+			name: "self dependency",
+			services: []service{
+				{name: "keystone", dependencies: []string{"keystone"}},
+			},
+			wantErr: "dependency cycle between services: keystone -> keystone",
+		},
+		{
+			name: "dependency cycle",
+			services: []service{
+				{name: "mariadb"},
+				{name: "keystone", dependencies: []string{"mariadb", "glance"}},
+				{name: "glance", dependencies: []string{"placement"}},
+				{name: "placement", dependencies: []string{"keystone"}},
+			},
+			wantErr: "dependency cycle between services: keystone -> glance -> placement -> keystone",
+		},
+	}
+
+	registered := serviceComponents
+	defer func() { serviceComponents = registered }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serviceComponents = []ServiceComponent{}
+			for _, s := range tt.services {
+				RegisterServiceComponent(&childCRComponent{name: s.name, dependencies: s.dependencies})
+			}
+
+			waves, err := serviceWaves()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("serviceWaves() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("serviceWaves() unexpected error: %v", err)
+			}
+
+			got := [][]string{}
+			for _, wave := range waves {
+				names := []string{}
+				for _, component := range wave {
+					names = append(names, component.Name())
+				}
+				got = append(got, names)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("serviceWaves() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
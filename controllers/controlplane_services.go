@@ -0,0 +1,417 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	uns "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
+	bindatautil "github.com/openstack-k8s-operators/openstack-cluster-operator/pkg/bindata_util"
+)
+
+// ServiceComponent - a service of the control plane deployed by the ControlPlaneReconciler
+type ServiceComponent interface {
+	// Name - short name of the service, used in the service label and the status
+	Name() string
+	// ManifestDir - directory below ManifestPath holding the templates of the service
+	ManifestDir() string
+	// Enabled - whether the service gets deployed for the ControlPlane
+	Enabled(instance *controlplanev1beta1.ControlPlane) bool
+	// RenderData - adds the template data of the service to data
+	RenderData(instance *controlplanev1beta1.ControlPlane, data *bindatautil.RenderData) error
+	// Dependencies - names of the services which have to be ready before this one gets deployed
+	Dependencies() []string
+	// Kinds - kinds of the objects deployed for the service, besides Secrets and ConfigMaps
+	Kinds() []schema.GroupVersionKind
+	// Status - reads back the deployed service and reports its readiness
+	Status(ctx context.Context, c client.Client, instance *controlplanev1beta1.ControlPlane) controlplanev1beta1.ServiceStatus
+	// Credentials - keys the user supplied Secret of the service has to provide,
+	// mapped to the generated credentials they replace
+	Credentials(instance *controlplanev1beta1.ControlPlane) map[string]string
+	// UserSecret - name of the user supplied Secret of the service, empty if not set
+	UserSecret(instance *controlplanev1beta1.ControlPlane) string
+	// Images - container images deployed for the service
+	Images() []ServiceImage
+	// Object - kind and name of the object deploying the service, which owns its PVCs
+	Object() (schema.GroupVersionKind, string)
+	// Storage - PVC settings of the service, nil if it does not store its data on PVCs
+	Storage(instance *controlplanev1beta1.ControlPlane) *controlplanev1beta1.StorageSpec
+}
+
+// serviceComponents - registered services, in the order they get rendered
+var serviceComponents = []ServiceComponent{}
+
+// RegisterServiceComponent - adds a service to the ones deployed for each ControlPlane.
+// It is meant to be called from init(), registering a name twice panics. Besides
+// its settings in the ControlPlane API and its templates, a service only needs
+// to be registered, its credentials, images and storage get read from the component.
+func RegisterServiceComponent(component ServiceComponent) {
+	if getServiceComponent(component.Name()) != nil {
+		panic(fmt.Sprintf("service component %s registered twice", component.Name()))
+	}
+	serviceComponents = append(serviceComponents, component)
+}
+
+// ServiceComponents - the registered services, in registration order
+func ServiceComponents() []ServiceComponent {
+	return append([]ServiceComponent{}, serviceComponents...)
+}
+
+// getServiceComponent - the registered service named name, nil if there is none
+func getServiceComponent(name string) ServiceComponent {
+	for _, component := range serviceComponents {
+		if component.Name() == name {
+			return component
+		}
+	}
+	return nil
+}
+
+// childCRComponent - a service deployed as a single CR of its own operator
+type childCRComponent struct {
+	name         string
+	gvk          schema.GroupVersionKind
+	objectName   string
+	dependencies []string
+	// status fields the child operator only populates once the service got deployed
+	readyFields []string
+	// enabled toggle of the service in the ControlPlane spec
+	enabled func(spec *controlplanev1beta1.ControlPlaneSpec) *bool
+	// template data of the service, besides the common data of getRenderData
+	renderData func(spec *controlplanev1beta1.ControlPlaneSpec, data *bindatautil.RenderData)
+	// keys of the user supplied Secret mapped to the generated credentials they replace
+	credentials map[string]string
+	// credentials depending on the spec, e.g. one per nova cell, added to credentials
+	specCredentials func(instance *controlplanev1beta1.ControlPlane) map[string]string
+	// user supplied Secret of the service in the ControlPlane spec
+	secret func(spec *controlplanev1beta1.ControlPlaneSpec) string
+	// container images of the service
+	images []ServiceImage
+	// PVC settings in the ControlPlane spec, nil if the service does not use PVCs
+	storage func(spec *controlplanev1beta1.ControlPlaneSpec) *controlplanev1beta1.StorageSpec
+}
+
+// Name -
+func (c *childCRComponent) Name() string {
+	return c.name
+}
+
+// ManifestDir -
+func (c *childCRComponent) ManifestDir() string {
+	return c.name
+}
+
+// Enabled - services are enabled unless explicitly disabled in the spec
+func (c *childCRComponent) Enabled(instance *controlplanev1beta1.ControlPlane) bool {
+	enabled := c.enabled(&instance.Spec)
+	return enabled == nil || *enabled
+}
+
+// RenderData -
+func (c *childCRComponent) RenderData(instance *controlplanev1beta1.ControlPlane, data *bindatautil.RenderData) error {
+	if c.renderData != nil {
+		c.renderData(&instance.Spec, data)
+	}
+	return nil
+}
+
+// Dependencies -
+func (c *childCRComponent) Dependencies() []string {
+	return c.dependencies
+}
+
+// Kinds -
+func (c *childCRComponent) Kinds() []schema.GroupVersionKind {
+	return []schema.GroupVersionKind{c.gvk}
+}
+
+// Credentials -
+func (c *childCRComponent) Credentials(instance *controlplanev1beta1.ControlPlane) map[string]string {
+	if c.specCredentials == nil {
+		return c.credentials
+	}
+	credentials := map[string]string{}
+	for key, credential := range c.credentials {
+		credentials[key] = credential
+	}
+	for key, credential := range c.specCredentials(instance) {
+		credentials[key] = credential
+	}
+	return credentials
+}
+
+// UserSecret -
+func (c *childCRComponent) UserSecret(instance *controlplanev1beta1.ControlPlane) string {
+	if c.secret == nil {
+		return ""
+	}
+	return c.secret(&instance.Spec)
+}
+
+// Images -
+func (c *childCRComponent) Images() []ServiceImage {
+	return c.images
+}
+
+// Object -
+func (c *childCRComponent) Object() (schema.GroupVersionKind, string) {
+	return c.gvk, c.objectName
+}
+
+// Storage -
+func (c *childCRComponent) Storage(instance *controlplanev1beta1.ControlPlane) *controlplanev1beta1.StorageSpec {
+	if c.storage == nil {
+		return nil
+	}
+	return c.storage(&instance.Spec)
+}
+
+// Status reads back the child CR and derives the service status from it
+func (c *childCRComponent) Status(ctx context.Context, cl client.Client, instance *controlplanev1beta1.ControlPlane) controlplanev1beta1.ServiceStatus {
+	status := controlplanev1beta1.ServiceStatus{
+		Name:       c.name,
+		Kind:       c.gvk.Kind,
+		ObjectName: c.objectName,
+	}
+
+	obj := &uns.Unstructured{}
+	obj.SetGroupVersionKind(c.gvk)
+	err := cl.Get(ctx, types.NamespacedName{Name: c.objectName, Namespace: instance.Namespace}, obj)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			status.Reason = "NotFound"
+			status.Message = fmt.Sprintf("%s %s does not exist yet", c.gvk.Kind, c.objectName)
+			return status
+		}
+		status.Reason = "Error"
+		status.Message = err.Error()
+		return status
+	}
+
+	status.ObservedGeneration = obj.GetGeneration()
+	status.ConfigHash = obj.GetAnnotations()[customConfigHashAnnotation]
+	status.Ready, status.Reason, status.Message = isChildReady(obj, c.readyFields)
+	return status
+}
+
+func init() {
+	RegisterServiceComponent(&childCRComponent{
+		name:        "mariadb",
+		gvk:         schema.GroupVersionKind{Group: "database.openstack.org", Version: "v1beta1", Kind: "MariaDB"},
+		objectName:  "mariadb",
+		readyFields: []string{"dbInitHash"},
+		enabled:     func(spec *controlplanev1beta1.ControlPlaneSpec) *bool { return spec.MariaDB.Enabled },
+		renderData: func(spec *controlplanev1beta1.ControlPlaneSpec, data *bindatautil.RenderData) {
+			data.Data["MariaDBStorage"] = spec.MariaDB.Storage
+		},
+		credentials: map[string]string{
+			"DbRootPassword": "MariaDBRootPassword",
+		},
+		secret: func(spec *controlplanev1beta1.ControlPlaneSpec) string { return spec.MariaDB.Secret },
+		images: []ServiceImage{
+			{Name: "MariaDB", Field: "mariadb", RelatedImage: "mariadb", Default: "quay.io/tripleotrain/centos-binary-mariadb:current-tripleo"},
+		},
+		storage: func(spec *controlplanev1beta1.ControlPlaneSpec) *controlplanev1beta1.StorageSpec {
+			return &spec.MariaDB.Storage
+		},
+	})
+	RegisterServiceComponent(&childCRComponent{
+		name:        "interconnect",
+		gvk:         schema.GroupVersionKind{Group: "interconnectedcloud.github.io", Version: "v1alpha1", Kind: "Interconnect"},
+		objectName:  interconnectName,
+		readyFields: []string{"podNames"},
+		enabled:     func(spec *controlplanev1beta1.ControlPlaneSpec) *bool { return spec.Interconnect.Enabled },
+		renderData: func(spec *controlplanev1beta1.ControlPlaneSpec, data *bindatautil.RenderData) {
+			data.Data["InterconnectReplicas"] = spec.Interconnect.Replicas
+		},
+		credentials: map[string]string{
+			"osp": "TransportPassword",
+		},
+		// the password of each nova cell user, keyed by the cell name
+		specCredentials: func(instance *controlplanev1beta1.ControlPlane) map[string]string {
+			credentials := map[string]string{}
+			for _, cell := range getNovaCells(instance) {
+				credentials[cell.Name] = cell.CredentialKey
+			}
+			return credentials
+		},
+		secret: func(spec *controlplanev1beta1.ControlPlaneSpec) string { return spec.Interconnect.Secret },
+	})
+	RegisterServiceComponent(&childCRComponent{
+		name:         "keystone",
+		gvk:          schema.GroupVersionKind{Group: "keystone.openstack.org", Version: "v1beta1", Kind: "KeystoneAPI"},
+		objectName:   "keystone",
+		dependencies: []string{"mariadb"},
+		readyFields:  []string{"bootstrapHash"},
+		enabled:      func(spec *controlplanev1beta1.ControlPlaneSpec) *bool { return spec.Keystone.Enabled },
+		renderData: func(spec *controlplanev1beta1.ControlPlaneSpec, data *bindatautil.RenderData) {
+			data.Data["KeystoneReplicas"] = spec.Keystone.Replicas
+		},
+		credentials: map[string]string{
+			"AdminPassword":    "KeystoneAdminPassword",
+			"DatabasePassword": "KeystoneDatabasePassword",
+		},
+		secret: func(spec *controlplanev1beta1.ControlPlaneSpec) string { return spec.Keystone.Secret },
+		images: []ServiceImage{
+			{Name: "Keystone", Field: "keystone", RelatedImage: "keystone", Default: "quay.io/tripleotrain/centos-binary-keystone:current-tripleo"},
+		},
+	})
+	RegisterServiceComponent(&childCRComponent{
+		name:         "glance",
+		gvk:          schema.GroupVersionKind{Group: "glance.openstack.org", Version: "v1beta1", Kind: "GlanceAPI"},
+		objectName:   "glanceapi",
+		dependencies: []string{"mariadb", "keystone"},
+		readyFields:  []string{"deploymentHash"},
+		enabled:      func(spec *controlplanev1beta1.ControlPlaneSpec) *bool { return spec.Glance.Enabled },
+		renderData: func(spec *controlplanev1beta1.ControlPlaneSpec, data *bindatautil.RenderData) {
+			data.Data["GlanceReplicas"] = spec.Glance.Replicas
+			data.Data["GlanceBackend"] = spec.Glance.Backend
+		},
+		credentials: map[string]string{
+			"DatabasePassword":           "GlanceDatabasePassword",
+			"GlanceKeystoneAuthPassword": "GlanceKeystoneAuthPassword",
+		},
+		secret: func(spec *controlplanev1beta1.ControlPlaneSpec) string { return spec.Glance.Secret },
+		images: []ServiceImage{
+			{Name: "GlanceAPI", Field: "glanceAPI", RelatedImage: "glance-api", Default: "quay.io/tripleotrain/centos-binary-glance-api:current-tripleo"},
+		},
+		storage: func(spec *controlplanev1beta1.ControlPlaneSpec) *controlplanev1beta1.StorageSpec {
+			if spec.Glance.Backend.Type != controlplanev1beta1.GlanceBackendFile {
+				return nil
+			}
+			return &spec.Glance.Backend.Storage
+		},
+	})
+	RegisterServiceComponent(&childCRComponent{
+		name:         "placement",
+		gvk:          schema.GroupVersionKind{Group: "placement.openstack.org", Version: "v1beta1", Kind: "PlacementAPI"},
+		objectName:   "placement",
+		dependencies: []string{"mariadb", "keystone"},
+		readyFields:  []string{"deploymentHash"},
+		enabled:      func(spec *controlplanev1beta1.ControlPlaneSpec) *bool { return spec.Placement.Enabled },
+		renderData: func(spec *controlplanev1beta1.ControlPlaneSpec, data *bindatautil.RenderData) {
+			data.Data["PlacementReplicas"] = spec.Placement.Replicas
+		},
+		credentials: map[string]string{
+			"DatabasePassword":              "PlacementDatabasePassword",
+			"PlacementKeystoneAuthPassword": "PlacementKeystoneAuthPassword",
+		},
+		secret: func(spec *controlplanev1beta1.ControlPlaneSpec) string { return spec.Placement.Secret },
+		images: []ServiceImage{
+			{Name: "PlacementAPI", Field: "placementAPI", RelatedImage: "placement-api", Default: "quay.io/tripleotrain/centos-binary-placement-api:current-tripleo"},
+		},
+	})
+	RegisterServiceComponent(&childCRComponent{
+		name:         "neutron",
+		gvk:          schema.GroupVersionKind{Group: "neutron.openstack.org", Version: "v1beta1", Kind: "NeutronAPI"},
+		objectName:   "neutronapi",
+		dependencies: []string{"mariadb", "keystone"},
+		readyFields:  []string{"deploymentHash"},
+		enabled:      func(spec *controlplanev1beta1.ControlPlaneSpec) *bool { return spec.Neutron.Enabled },
+		renderData: func(spec *controlplanev1beta1.ControlPlaneSpec, data *bindatautil.RenderData) {
+			data.Data["NeutronAPIReplicas"] = spec.Neutron.Replicas
+		},
+		credentials: map[string]string{
+			"DatabasePassword":            "NeutronDatabasePassword",
+			"NeutronKeystoneAuthPassword": "NeutronKeystoneAuthPassword",
+		},
+		secret: func(spec *controlplanev1beta1.ControlPlaneSpec) string { return spec.Neutron.Secret },
+		images: []ServiceImage{
+			{Name: "NeutronServer", Field: "neutronServer", RelatedImage: "neutron-server", Default: "quay.io/tripleotrain/centos-binary-neutron-server-ovn:current-tripleo"},
+		},
+	})
+	RegisterServiceComponent(&childCRComponent{
+		name:         "cinder",
+		gvk:          schema.GroupVersionKind{Group: "cinder.openstack.org", Version: "v1beta1", Kind: "Cinder"},
+		objectName:   "cinder",
+		dependencies: []string{"mariadb", "interconnect", "keystone", "glance"},
+		readyFields:  []string{"dbSyncHash"},
+		enabled:      func(spec *controlplanev1beta1.ControlPlaneSpec) *bool { return spec.Cinder.Enabled },
+		renderData: func(spec *controlplanev1beta1.ControlPlaneSpec, data *bindatautil.RenderData) {
+			data.Data["CinderAPIReplicas"] = spec.Cinder.CinderAPIReplicas
+			data.Data["CinderBackupReplicas"] = spec.Cinder.CinderBackupReplicas
+			data.Data["CinderSchedulerReplicas"] = spec.Cinder.CinderSchedulerReplicas
+			// backends without replicas of their own run the default number of replicas
+			backends := []controlplanev1beta1.CinderVolumeBackendSpec{}
+			for _, backend := range spec.Cinder.VolumeBackends {
+				if backend.Replicas == nil {
+					backend.Replicas = spec.Cinder.CinderVolumeReplicas
+				}
+				backends = append(backends, backend)
+			}
+			data.Data["CinderVolumeBackends"] = backends
+		},
+		credentials: map[string]string{
+			"DatabasePassword":           "CinderDatabasePassword",
+			"CinderKeystoneAuthPassword": "CinderKeystoneAuthPassword",
+		},
+		secret: func(spec *controlplanev1beta1.ControlPlaneSpec) string { return spec.Cinder.Secret },
+		images: []ServiceImage{
+			{Name: "CinderAPI", Field: "cinderAPI", RelatedImage: "cinder-api", Default: "quay.io/tripleotrain/centos-binary-cinder-api:current-tripleo"},
+			{Name: "CinderScheduler", Field: "cinderScheduler", RelatedImage: "cinder-scheduler", Default: "quay.io/tripleotrain/centos-binary-cinder-scheduler:current-tripleo"},
+			{Name: "CinderBackup", Field: "cinderBackup", RelatedImage: "cinder-backup", Default: "quay.io/tripleotrain/centos-binary-cinder-backup:current-tripleo"},
+			{Name: "CinderVolume", Field: "cinderVolume", RelatedImage: "cinder-volume", Default: "quay.io/tripleotrain/centos-binary-cinder-volume:current-tripleo"},
+		},
+	})
+	RegisterServiceComponent(&childCRComponent{
+		name:         "nova",
+		gvk:          schema.GroupVersionKind{Group: "nova.openstack.org", Version: "v1beta1", Kind: "Nova"},
+		objectName:   "nova",
+		dependencies: []string{"mariadb", "interconnect", "keystone", "placement", "neutron"},
+		readyFields:  []string{"dbSyncHash"},
+		enabled:      func(spec *controlplanev1beta1.ControlPlaneSpec) *bool { return spec.Nova.Enabled },
+		renderData: func(spec *controlplanev1beta1.ControlPlaneSpec, data *bindatautil.RenderData) {
+			data.Data["NovaAPIReplicas"] = spec.Nova.NovaAPIReplicas
+			data.Data["NovaConductorReplicas"] = spec.Nova.NovaConductorReplicas
+			data.Data["NovaSchedulerReplicas"] = spec.Nova.NovaSchedulerReplicas
+			// cells without replicas of their own run the replicas of the top-level settings
+			cells := []controlplanev1beta1.NovaCellSpec{}
+			for _, cell := range spec.Nova.Cells {
+				if cell.NovaConductorReplicas == nil {
+					cell.NovaConductorReplicas = spec.Nova.NovaConductorReplicas
+				}
+				if cell.NovaMetadataReplicas == nil {
+					cell.NovaMetadataReplicas = spec.Nova.NovaMetadataReplicas
+				}
+				if cell.NovaNoVNCProxyReplicas == nil {
+					cell.NovaNoVNCProxyReplicas = spec.Nova.NovaNoVNCProxyReplicas
+				}
+				cells = append(cells, cell)
+			}
+			data.Data["NovaCells"] = cells
+		},
+		credentials: map[string]string{
+			"DatabasePassword":         "NovaDatabasePassword",
+			"NovaKeystoneAuthPassword": "NovaKeystoneAuthPassword",
+		},
+		secret: func(spec *controlplanev1beta1.ControlPlaneSpec) string { return spec.Nova.Secret },
+		images: []ServiceImage{
+			{Name: "NovaAPI", Field: "novaAPI", RelatedImage: "nova-api", Default: "quay.io/tripleotrain/centos-binary-nova-api:current-tripleo"},
+			{Name: "NovaScheduler", Field: "novaScheduler", RelatedImage: "nova-scheduler", Default: "quay.io/tripleotrain/centos-binary-nova-scheduler:current-tripleo"},
+			{Name: "NovaConductor", Field: "novaConductor", RelatedImage: "nova-conductor", Default: "quay.io/tripleotrain/centos-binary-nova-conductor:current-tripleo"},
+			{Name: "NovaMetadata", Field: "novaMetadata", RelatedImage: "nova-metadata", Default: "quay.io/tripleotrain/centos-binary-nova-api:current-tripleo"},
+			{Name: "NovaNoVNCProxy", Field: "novaNoVNCProxy", RelatedImage: "nova-novncproxy", Default: "quay.io/tripleotrain/centos-binary-nova-novncproxy:current-tripleo"},
+		},
+	})
+}
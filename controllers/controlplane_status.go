@@ -0,0 +1,187 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	uns "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
+)
+
+// readyConditionTypes - condition types child operators use to flag a deployed service
+var readyConditionTypes = []string{"Ready", "Deployed", "Available"}
+
+// isChildReady checks the status of an unstructured child CR. A child is
+// ready if it reports a ready condition, or - for operators which do not
+// use conditions - if all readyFields are populated in its status.
+func isChildReady(obj *uns.Unstructured, readyFields []string) (bool, string, string) {
+	status, found, _ := uns.NestedMap(obj.Object, "status")
+	if !found || len(status) == 0 {
+		return false, "Pending", "waiting for status to be reported"
+	}
+
+	if observed, found, _ := uns.NestedInt64(status, "observedGeneration"); found && observed < obj.GetGeneration() {
+		return false, "Pending", fmt.Sprintf("generation %d not yet observed", obj.GetGeneration())
+	}
+
+	if conditions, found, _ := uns.NestedSlice(status, "conditions"); found {
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, t := range readyConditionTypes {
+				if condition["type"] != t {
+					continue
+				}
+				msg, _ := condition["message"].(string)
+				if condition["status"] == string(metav1.ConditionTrue) {
+					return true, t, msg
+				}
+				return false, "Pending", msg
+			}
+		}
+	}
+
+	missing := []string{}
+	for _, field := range readyFields {
+		val, found := status[field]
+		if !found || isEmptyValue(val) {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return false, "Pending", fmt.Sprintf("waiting for status %s", strings.Join(missing, ", "))
+	}
+	return true, "Deployed", ""
+}
+
+func isEmptyValue(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	case int64:
+		return v == 0
+	case float64:
+		return v == 0
+	}
+	return false
+}
+
+// updateStatus refreshes the per service status and the aggregated conditions
+// of the ControlPlane. reconcileErr is the error of the current reconcile run,
+// if any, and gets reported via the Degraded condition.
+func (r *ControlPlaneReconciler) updateStatus(ctx context.Context, instance *controlplanev1beta1.ControlPlane, reconcileErr error) (bool, error) {
+	ready := true
+	notReady := []string{}
+	services := []controlplanev1beta1.ServiceStatus{}
+	waves, _ := serviceWaves()
+	for i, wave := range waves {
+		for _, component := range wave {
+			if !component.Enabled(instance) {
+				services = append(services, controlplanev1beta1.ServiceStatus{
+					Name:   component.Name(),
+					Reason: "Disabled",
+				})
+				continue
+			}
+			s := component.Status(ctx, r.Client, instance)
+			if !s.Ready && int32(i+1) > instance.Status.Rollout.CurrentWave {
+				s.Reason = "WaitingForDependencies"
+				s.Message = fmt.Sprintf("deployed with wave %d, waiting for wave %d", i+1, instance.Status.Rollout.CurrentWave)
+			}
+			if !s.Ready {
+				ready = false
+				notReady = append(notReady, s.Name)
+			}
+			services = append(services, s)
+		}
+	}
+	instance.Status.Services = services
+	instance.Status.ObservedGeneration = instance.Generation
+
+	generation := instance.Generation
+	if reconcileErr != nil {
+		controlplanev1beta1.SetCondition(&instance.Status.Conditions, controlplanev1beta1.Condition{
+			Type:               controlplanev1beta1.ConditionDegraded,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "ReconcileError",
+			Message:            reconcileErr.Error(),
+		})
+	} else {
+		controlplanev1beta1.SetCondition(&instance.Status.Conditions, controlplanev1beta1.Condition{
+			Type:               controlplanev1beta1.ConditionDegraded,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "AsExpected",
+		})
+	}
+
+	if ready && reconcileErr == nil {
+		controlplanev1beta1.SetCondition(&instance.Status.Conditions, controlplanev1beta1.Condition{
+			Type:               controlplanev1beta1.ConditionReady,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "AllServicesReady",
+			Message:            "all control plane services are ready",
+		})
+		controlplanev1beta1.SetCondition(&instance.Status.Conditions, controlplanev1beta1.Condition{
+			Type:               controlplanev1beta1.ConditionReconciling,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "Reconciled",
+		})
+	} else {
+		reason := "ServicesNotReady"
+		msg := fmt.Sprintf("waiting for services: %s", strings.Join(notReady, ", "))
+		if reconcileErr != nil {
+			reason = "ReconcileError"
+			msg = reconcileErr.Error()
+		}
+		controlplanev1beta1.SetCondition(&instance.Status.Conditions, controlplanev1beta1.Condition{
+			Type:               controlplanev1beta1.ConditionReady,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             reason,
+			Message:            msg,
+		})
+		controlplanev1beta1.SetCondition(&instance.Status.Conditions, controlplanev1beta1.Condition{
+			Type:               controlplanev1beta1.ConditionReconciling,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             reason,
+			Message:            msg,
+		})
+	}
+
+	if err := r.Client.Status().Update(ctx, instance); err != nil {
+		return false, err
+	}
+	return ready, nil
+}
@@ -0,0 +1,182 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
+)
+
+// reconcileStorage compares the PVCs created by the child operators of the
+// services of a wave with the sizes requested in the ControlPlane spec. PVCs
+// smaller than requested get expanded if their storage class allows it, the
+// outcome is recorded in the storage status. The status of the services of
+// the other waves is kept.
+func (r *ControlPlaneReconciler) reconcileStorage(ctx context.Context, instance *controlplanev1beta1.ControlPlane, wave []ServiceComponent) error {
+	inWave := map[string]bool{}
+	for _, component := range wave {
+		inWave[component.Name()] = true
+	}
+	statuses := []controlplanev1beta1.StorageStatus{}
+	for _, status := range instance.Status.Storage {
+		if !inWave[status.Service] {
+			statuses = append(statuses, status)
+		}
+	}
+
+	for _, component := range wave {
+		storage := component.Storage(instance)
+		if storage == nil || !component.Enabled(instance) {
+			continue
+		}
+		requested, err := resource.ParseQuantity(storage.Size)
+		if err != nil {
+			return err
+		}
+
+		gvk, objectName := component.Object()
+		claims, err := r.ownedClaims(ctx, instance.Namespace, gvk.Kind, objectName)
+		if err != nil {
+			return err
+		}
+		if len(claims) == 0 {
+			statuses = append(statuses, controlplanev1beta1.StorageStatus{
+				Service:       component.Name(),
+				StorageClass:  storage.StorageClass,
+				RequestedSize: storage.Size,
+				State:         controlplanev1beta1.StoragePending,
+				Message:       fmt.Sprintf("%s %s did not create its PVC yet", gvk.Kind, objectName),
+			})
+			continue
+		}
+
+		for i := range claims {
+			status, err := r.expandClaim(ctx, &claims[i], requested)
+			if err != nil {
+				return err
+			}
+			status.Service = component.Name()
+			status.RequestedSize = storage.Size
+			statuses = append(statuses, status)
+		}
+	}
+
+	instance.Status.Storage = statuses
+	return nil
+}
+
+// ownedClaims - PVCs in namespace owned by the child CR of kind named name
+func (r *ControlPlaneReconciler) ownedClaims(ctx context.Context, namespace, kind, name string) ([]corev1.PersistentVolumeClaim, error) {
+	claimList := &corev1.PersistentVolumeClaimList{}
+	if err := r.Client.List(ctx, claimList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	claims := []corev1.PersistentVolumeClaim{}
+	for _, claim := range claimList.Items {
+		for _, ref := range claim.OwnerReferences {
+			if ref.Kind == kind && ref.Name == name {
+				claims = append(claims, claim)
+				break
+			}
+		}
+	}
+	return claims, nil
+}
+
+// expandClaim raises the request of a bound PVC to the requested size if
+// its storage class allows volume expansion, and reports the resize state
+func (r *ControlPlaneReconciler) expandClaim(ctx context.Context, claim *corev1.PersistentVolumeClaim, requested resource.Quantity) (controlplanev1beta1.StorageStatus, error) {
+	status := controlplanev1beta1.StorageStatus{
+		ClaimName: claim.Name,
+	}
+	if claim.Spec.StorageClassName != nil {
+		status.StorageClass = *claim.Spec.StorageClassName
+	}
+
+	if claim.Status.Phase != corev1.ClaimBound {
+		status.State = controlplanev1beta1.StoragePending
+		status.Message = fmt.Sprintf("PVC is %s", claim.Status.Phase)
+		return status, nil
+	}
+
+	capacity := claim.Status.Capacity[corev1.ResourceStorage]
+	status.CurrentSize = capacity.String()
+	if capacity.Cmp(requested) >= 0 {
+		status.State = controlplanev1beta1.StorageReady
+		return status, nil
+	}
+
+	for _, c := range claim.Status.Conditions {
+		if c.Type == corev1.PersistentVolumeClaimFileSystemResizePending && c.Status == corev1.ConditionTrue {
+			status.State = controlplanev1beta1.StorageFileSystemResizePending
+			status.Message = "the volume got resized, the file system gets resized once a pod using the PVC restarts"
+			return status, nil
+		}
+	}
+
+	claimRequest := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+	if claimRequest.Cmp(requested) < 0 {
+		if status.StorageClass == "" {
+			status.State = controlplanev1beta1.StorageExpansionNotSupported
+			status.Message = "PVCs without storage class can not be expanded"
+			return status, nil
+		}
+		storageClass := &storagev1.StorageClass{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: status.StorageClass}, storageClass)
+		if err != nil {
+			return status, err
+		}
+		if storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion {
+			status.State = controlplanev1beta1.StorageExpansionNotSupported
+			status.Message = fmt.Sprintf("storage class %s does not allow volume expansion", storageClass.Name)
+			return status, nil
+		}
+
+		patch := client.MergeFrom(claim.DeepCopy())
+		if claim.Spec.Resources.Requests == nil {
+			claim.Spec.Resources.Requests = corev1.ResourceList{}
+		}
+		claim.Spec.Resources.Requests[corev1.ResourceStorage] = requested
+		if err := r.Client.Patch(ctx, claim, patch); err != nil {
+			return status, err
+		}
+		r.Log.Info("Expanding PVC", "PVC", claim.Name, "From", capacity.String(), "To", requested.String())
+	}
+
+	status.State = controlplanev1beta1.StorageExpanding
+	status.Message = fmt.Sprintf("resizing the volume from %s to %s", capacity.String(), requested.String())
+	return status, nil
+}
+
+// storageSettled - true if all PVCs provide the requested size or can not be expanded
+func storageSettled(instance *controlplanev1beta1.ControlPlane) bool {
+	for _, s := range instance.Status.Storage {
+		if s.State != controlplanev1beta1.StorageReady && s.State != controlplanev1beta1.StorageExpansionNotSupported {
+			return false
+		}
+	}
+	return true
+}
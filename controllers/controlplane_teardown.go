@@ -0,0 +1,186 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	uns "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
+)
+
+// controlPlaneFinalizer - keeps the ControlPlane until all its services got torn down
+const controlPlaneFinalizer = "controlplane.openstack.org/teardown"
+
+// managedKinds - kinds of all objects the registered services deploy
+func managedKinds() []schema.GroupVersionKind {
+	kinds := append([]schema.GroupVersionKind{}, pruneKinds...)
+	seen := map[schema.GroupVersionKind]bool{}
+	for _, gvk := range kinds {
+		seen[gvk] = true
+	}
+	for _, component := range serviceComponents {
+		for _, gvk := range component.Kinds() {
+			if !seen[gvk] {
+				seen[gvk] = true
+				kinds = append(kinds, gvk)
+			}
+		}
+	}
+	return kinds
+}
+
+// ensureFinalizer adds the teardown finalizer to the ControlPlane, returns true if it had to be added
+func (r *ControlPlaneReconciler) ensureFinalizer(ctx context.Context, instance *controlplanev1beta1.ControlPlane) (bool, error) {
+	if controllerutil.ContainsFinalizer(instance, controlPlaneFinalizer) {
+		return false, nil
+	}
+	controllerutil.AddFinalizer(instance, controlPlaneFinalizer)
+	if err := r.Client.Update(ctx, instance); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// teardown deletes the services of a deleted ControlPlane in reverse
+// dependency order. A wave only gets deleted once the objects of the later
+// waves are gone, so e.g. nova can still clean up its database before
+// mariadb gets removed. The Secrets and ConfigMaps the services might need
+// for their cleanup are kept until all services are gone. Afterwards the
+// remaining labelled objects, also those in other namespaces which have no
+// owner reference if the operator may list them, get deleted and the
+// finalizer is removed.
+func (r *ControlPlaneReconciler) teardown(ctx context.Context, instance *controlplanev1beta1.ControlPlane) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(instance, controlPlaneFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	waves, err := serviceWaves()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	deleted := []string{}
+	for i := len(waves) - 1; i >= 0; i-- {
+		names := []string{}
+		kinds := []schema.GroupVersionKind{}
+		for _, component := range waves[i] {
+			names = append(names, component.Name())
+			kinds = append(kinds, component.Kinds()...)
+		}
+		requirement, err := labels.NewRequirement(serviceLabelSelector, selection.In, names)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		pending, err := r.deleteLabelledObjects(ctx, instance, instance.Namespace, kinds, *requirement)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if pending > 0 {
+			r.Log.Info("Waiting for services to be deleted", "Services", names, "Objects", pending)
+			return ctrl.Result{RequeueAfter: statusRequeueInterval}, r.setTeardownStatus(ctx, instance, names, deleted, pending)
+		}
+		deleted = append(names, deleted...)
+	}
+
+	// Secrets, ConfigMaps and objects which carry no service label
+	pending, err := r.deleteLabelledObjects(ctx, instance, metav1.NamespaceAll, managedKinds())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if pending > 0 {
+		r.Log.Info("Waiting for remaining objects to be deleted", "Objects", pending)
+		return ctrl.Result{RequeueAfter: statusRequeueInterval}, r.setTeardownStatus(ctx, instance, nil, deleted, pending)
+	}
+
+	r.Log.Info("ControlPlane torn down, removing finalizer", "ControlPlane", instance.Name)
+	controllerutil.RemoveFinalizer(instance, controlPlaneFinalizer)
+	return ctrl.Result{}, r.Client.Update(ctx, instance)
+}
+
+// deleteLabelledObjects deletes the objects of the given kinds in namespace,
+// or all namespaces, labelled for this ControlPlane and matching requirements.
+// It returns the number of objects which still exist, including the ones just
+// deleted. Without the permission to list a kind in all namespaces only the
+// namespace of the ControlPlane is looked at.
+func (r *ControlPlaneReconciler) deleteLabelledObjects(ctx context.Context, instance *controlplanev1beta1.ControlPlane, namespace string, kinds []schema.GroupVersionKind, requirements ...labels.Requirement) (int, error) {
+	selector := labels.SelectorFromSet(labels.Set{ownerUIDLabelSelector: string(instance.UID)}).Add(requirements...)
+
+	pending := 0
+	for _, gvk := range kinds {
+		list := &uns.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		err := r.Client.List(ctx, list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector})
+		if err != nil && namespace == metav1.NamespaceAll && k8s_errors.IsForbidden(err) {
+			r.Log.Info("Not allowed to list in all namespaces, skipping other namespaces", "Kind", gvk.String())
+			err = r.Client.List(ctx, list, client.InNamespace(instance.Namespace), client.MatchingLabelsSelector{Selector: selector})
+		}
+		if err != nil {
+			if meta.IsNoMatchError(err) || k8s_errors.IsNotFound(err) {
+				continue
+			}
+			return pending, err
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			pending++
+			if obj.GetDeletionTimestamp() != nil {
+				continue
+			}
+			r.Log.Info("Deleting object", "Kind", obj.GetKind(), "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+			if err := r.Client.Delete(ctx, obj); err != nil && !k8s_errors.IsNotFound(err) {
+				return pending, err
+			}
+		}
+	}
+	return pending, nil
+}
+
+// setTeardownStatus records the teardown progress in the ControlPlane status
+func (r *ControlPlaneReconciler) setTeardownStatus(ctx context.Context, instance *controlplanev1beta1.ControlPlane, deleting []string, deleted []string, pending int) error {
+	instance.Status.Teardown = controlplanev1beta1.TeardownStatus{
+		Deleting:       deleting,
+		Deleted:        deleted,
+		PendingObjects: int32(pending),
+	}
+
+	msg := fmt.Sprintf("waiting for %d objects to be deleted", pending)
+	if len(deleting) > 0 {
+		msg = fmt.Sprintf("deleting services: %s", strings.Join(deleting, ", "))
+	}
+	controlplanev1beta1.SetCondition(&instance.Status.Conditions, controlplanev1beta1.Condition{
+		Type:               controlplanev1beta1.ConditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             "TearingDown",
+		Message:            msg,
+	})
+	return r.Client.Status().Update(ctx, instance)
+}
@@ -0,0 +1,228 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	uns "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+
+	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
+)
+
+const (
+	// keystoneSecretName - Secret rendered for the KeystoneAPI, holds the admin password in use
+	keystoneSecretName = "keystone-secret"
+	// keystoneAdminPasswordKey - key of the admin password in the keystoneSecretName Secret
+	keystoneAdminPasswordKey = "AdminPassword"
+	// cloudName - name of the cloud in the generated clouds.yaml, selected via OS_CLOUD
+	cloudName = "default"
+)
+
+// errKeystoneEndpointPending - the KeystoneAPI of the ControlPlane does not report its endpoint yet
+var errKeystoneEndpointPending = errors.New("keystone endpoint of the ControlPlane is not available yet")
+
+// clientConfigMapName - ConfigMap mounted as clouds.yaml into the client pod
+func clientConfigMapName(instance *controlplanev1beta1.OpenStackClient) string {
+	if instance.Spec.ControlPlane != "" {
+		return fmt.Sprintf("%s-config", instance.Name)
+	}
+	return instance.Spec.OpenStackConfigMap
+}
+
+// clientSecretName - Secret mounted as secure.yaml into the client pod
+func clientSecretName(instance *controlplanev1beta1.OpenStackClient) string {
+	if instance.Spec.ControlPlane != "" {
+		return fmt.Sprintf("%s-config-secret", instance.Name)
+	}
+	return instance.Spec.OpenStackConfigSecret
+}
+
+// clientVolumes - volumes of the clouds.yaml and secure.yaml, shared by the client pod and command jobs
+func clientVolumes(instance *controlplanev1beta1.OpenStackClient) []corev1.Volume {
+	return []corev1.Volume{
+		{
+			Name: "openstack-config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: clientConfigMapName(instance),
+					},
+				},
+			},
+		},
+		{
+			Name: "openstack-config-secret",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: clientSecretName(instance),
+				},
+			},
+		},
+	}
+}
+
+// clientVolumeMounts - mounts of the clientVolumes where the openstack CLI looks for them
+func clientVolumeMounts() []corev1.VolumeMount {
+	return []corev1.VolumeMount{
+		{
+			Name:      "openstack-config",
+			MountPath: "/etc/openstack/clouds.yaml",
+			SubPath:   controlplanev1beta1.OpenStackClientConfigKey,
+		},
+		{
+			Name:      "openstack-config-secret",
+			MountPath: "/etc/openstack/secure.yaml",
+			SubPath:   controlplanev1beta1.OpenStackClientSecretKey,
+		},
+	}
+}
+
+// clientEnv - environment selecting the cloud of the clouds.yaml
+func clientEnv() []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{
+			Name:  "OS_CLOUD",
+			Value: cloudName,
+		},
+	}
+}
+
+// reconcileGeneratedConfig generates the clouds.yaml and secure.yaml of a
+// client referencing a ControlPlane. The admin password is read from the
+// Secret rendered for the KeystoneAPI, so rotated or user supplied
+// credentials get picked up once the ControlPlane applied them.
+func (r *OpenStackClientReconciler) reconcileGeneratedConfig(ctx context.Context, instance *controlplanev1beta1.OpenStackClient) error {
+	controlPlane := &controlplanev1beta1.ControlPlane{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: instance.Spec.ControlPlane, Namespace: instance.Namespace}, controlPlane)
+	if err != nil {
+		return err
+	}
+
+	authURL, err := r.keystoneEndpoint(ctx, controlPlane)
+	if err != nil {
+		return err
+	}
+
+	keystoneSecret := &corev1.Secret{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: keystoneSecretName, Namespace: controlPlane.Namespace}, keystoneSecret)
+	if err != nil {
+		return err
+	}
+
+	cloudsYAML, err := yaml.Marshal(map[string]interface{}{
+		"clouds": map[string]interface{}{
+			cloudName: map[string]interface{}{
+				"auth": map[string]interface{}{
+					"auth_url":            authURL,
+					"username":            "admin",
+					"project_name":        "admin",
+					"user_domain_name":    "Default",
+					"project_domain_name": "Default",
+				},
+				"region_name":          "regionOne",
+				"identity_api_version": 3,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	secureYAML, err := yaml.Marshal(map[string]interface{}{
+		"clouds": map[string]interface{}{
+			cloudName: map[string]interface{}{
+				"auth": map[string]interface{}{
+					"password": string(keystoneSecret.Data[keystoneAdminPasswordKey]),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clientConfigMapName(instance),
+			Namespace: instance.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		configMap.Data = map[string]string{
+			controlplanev1beta1.OpenStackClientConfigKey: string(cloudsYAML),
+		}
+		return controllerutil.SetControllerReference(instance, configMap, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		r.Log.Info("Client configuration reconciled", "ConfigMap", configMap.Name, "Operation", op)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clientSecretName(instance),
+			Namespace: instance.Namespace,
+		},
+	}
+	op, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Data = map[string][]byte{
+			controlplanev1beta1.OpenStackClientSecretKey: secureYAML,
+		}
+		return controllerutil.SetControllerReference(instance, secret, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		r.Log.Info("Client credentials reconciled", "Secret", secret.Name, "Operation", op)
+	}
+	return nil
+}
+
+// keystoneEndpoint - public endpoint the KeystoneAPI of the ControlPlane reports in its status
+func (r *OpenStackClientReconciler) keystoneEndpoint(ctx context.Context, controlPlane *controlplanev1beta1.ControlPlane) (string, error) {
+	component := getServiceComponent("keystone")
+	if component == nil {
+		return "", fmt.Errorf("keystone service is not registered")
+	}
+
+	gvk, objectName := component.Object()
+	keystoneAPI := &uns.Unstructured{}
+	keystoneAPI.SetGroupVersionKind(gvk)
+	err := r.Client.Get(ctx, types.NamespacedName{Name: objectName, Namespace: controlPlane.Namespace}, keystoneAPI)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint, _, err := uns.NestedString(keystoneAPI.Object, "status", "apiEndpoint")
+	if err != nil {
+		return "", err
+	}
+	if endpoint == "" {
+		return "", errKeystoneEndpointPending
+	}
+	return endpoint, nil
+}
@@ -26,13 +26,22 @@ import (
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/openstack-cluster-operator/pkg/util"
 )
 
+// configHashAnnotation - pod template annotation holding the hash of the mounted
+// clouds.yaml and secure.yaml, a change of the hash rolls out new client pods
+const configHashAnnotation = "controlplane.openstack.org/config-hash"
+
 // OpenStackClientReconciler reconciles a OpenStackClient object
 type OpenStackClientReconciler struct {
 	client.Client
@@ -57,22 +66,132 @@ func (r *OpenStackClientReconciler) Reconcile(req ctrl.Request) (ctrl.Result, er
 		}
 		return ctrl.Result{}, err
 	}
+	// the webhooks are not deployed everywhere, e.g. not by the CSV or when running locally
+	instance.Default()
+	if err := instance.Validate(r.Client); err != nil {
+		if !k8s_errors.IsInvalid(err) {
+			return ctrl.Result{}, err
+		}
+		// the referenced ConfigMaps and Secrets are watched, the client gets reconciled once they get fixed
+		r.Log.Info("Invalid OpenStackClient spec", "Error", err.Error())
+		return ctrl.Result{}, r.setNotReadyStatus(instance, "InvalidSpec", err)
+	}
+
+	if instance.Spec.ControlPlane != "" {
+		err = r.reconcileGeneratedConfig(context.TODO(), instance)
+		if err != nil && (k8s_errors.IsNotFound(err) || err == errKeystoneEndpointPending) {
+			// the ControlPlane and the keystone Secret are watched, the client gets reconciled once they are available
+			r.Log.Info("Waiting for the ControlPlane", "Error", err.Error())
+			return ctrl.Result{}, r.setNotReadyStatus(instance, "ConfigMissing", err)
+		}
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 
-	err = r.reconcileDeployment(instance)
+	configHash, err := r.configHash(instance)
 	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// the ConfigMap and Secret are watched, the client gets reconciled once they exist
+			r.Log.Info("Waiting for the client configuration", "Error", err.Error())
+			return ctrl.Result{}, r.setNotReadyStatus(instance, "ConfigMissing", err)
+		}
 		return ctrl.Result{}, err
 	}
-	return ctrl.Result{}, nil
+
+	clientDeployment, err := r.reconcileDeployment(instance, configHash)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, r.updateStatus(instance, clientDeployment, configHash)
 }
 
 // SetupWithManager func
 func (r *OpenStackClientReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// reconcile the clients mounting a changed ConfigMap or Secret
+	configHandler := &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.configToRequests)}
+	// reconcile the clients generating their configuration from a changed ControlPlane
+	controlPlaneHandler := &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.controlPlaneToRequests)}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&controlplanev1beta1.OpenStackClient{}).
+		Owns(&appsv1.Deployment{}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, configHandler).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, configHandler).
+		Watches(&source.Kind{Type: &controlplanev1beta1.ControlPlane{}}, controlPlaneHandler).
 		Complete(r)
 }
 
-func (r *OpenStackClientReconciler) reconcileDeployment(instance *controlplanev1beta1.OpenStackClient) error {
+// configToRequests maps a ConfigMap or Secret to reconcile requests of the
+// OpenStackClients in its namespace which mount it. The keystone Secret of a
+// ControlPlane maps to the clients generating their configuration from it.
+func (r *OpenStackClientReconciler) configToRequests(obj handler.MapObject) []reconcile.Request {
+	_, isSecret := obj.Object.(*corev1.Secret)
+	controlPlane := ""
+	if isSecret && obj.Meta.GetName() == keystoneSecretName {
+		controlPlane = obj.Meta.GetLabels()[ownerNameLabelSelector]
+	}
+
+	return r.clientsToRequests(obj.Meta.GetNamespace(), func(instance *controlplanev1beta1.OpenStackClient) bool {
+		if controlPlane != "" && instance.Spec.ControlPlane == controlPlane {
+			return true
+		}
+		if isSecret {
+			return clientSecretName(instance) == obj.Meta.GetName()
+		}
+		return clientConfigMapName(instance) == obj.Meta.GetName()
+	})
+}
+
+// controlPlaneToRequests maps a ControlPlane to reconcile requests of the
+// OpenStackClients generating their configuration from it
+func (r *OpenStackClientReconciler) controlPlaneToRequests(obj handler.MapObject) []reconcile.Request {
+	return r.clientsToRequests(obj.Meta.GetNamespace(), func(instance *controlplanev1beta1.OpenStackClient) bool {
+		return instance.Spec.ControlPlane == obj.Meta.GetName()
+	})
+}
+
+// clientsToRequests - reconcile requests of the OpenStackClients in namespace matching filter
+func (r *OpenStackClientReconciler) clientsToRequests(namespace string, filter func(instance *controlplanev1beta1.OpenStackClient) bool) []reconcile.Request {
+	clients := &controlplanev1beta1.OpenStackClientList{}
+	if err := r.Client.List(context.TODO(), clients, client.InNamespace(namespace)); err != nil {
+		r.Log.Error(err, "Unable to list OpenStackClients", "Namespace", namespace)
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for i := range clients.Items {
+		instance := &clients.Items[i]
+		if filter(instance) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+// configHash - hash of the contents of the ConfigMap and Secret mounted into the client pod
+func (r *OpenStackClientReconciler) configHash(instance *controlplanev1beta1.OpenStackClient) (string, error) {
+	configMap := &corev1.ConfigMap{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: clientConfigMapName(instance), Namespace: instance.Namespace}, configMap)
+	if err != nil {
+		return "", err
+	}
+	secret := &corev1.Secret{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: clientSecretName(instance), Namespace: instance.Namespace}, secret)
+	if err != nil {
+		return "", err
+	}
+
+	return util.CalculateHash(struct {
+		ConfigMapData       map[string]string
+		ConfigMapBinaryData map[string][]byte
+		SecretData          map[string][]byte
+	}{configMap.Data, configMap.BinaryData, secret.Data})
+}
+
+func (r *OpenStackClientReconciler) reconcileDeployment(instance *controlplanev1beta1.OpenStackClient, configHash string) (*appsv1.Deployment, error) {
 	clientDeployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      instance.Name,
@@ -80,28 +199,9 @@ func (r *OpenStackClientReconciler) reconcileDeployment(instance *controlplanev1
 		},
 	}
 
-	r.Log.Info("openstack-config-secret name", "Name", instance.Spec.OpenStackConfigSecret)
+	r.Log.Info("openstack-config-secret name", "Name", clientSecretName(instance))
 	_, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, clientDeployment, func() error {
-		clientDeployment.Spec.Template.Spec.Volumes = []corev1.Volume{
-			{
-				Name: "openstack-config",
-				VolumeSource: corev1.VolumeSource{
-					ConfigMap: &corev1.ConfigMapVolumeSource{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: instance.Spec.OpenStackConfigMap,
-						},
-					},
-				},
-			},
-			{
-				Name: "openstack-config-secret",
-				VolumeSource: corev1.VolumeSource{
-					Secret: &corev1.SecretVolumeSource{
-						SecretName: instance.Spec.OpenStackConfigSecret,
-					},
-				},
-			},
-		}
+		clientDeployment.Spec.Template.Spec.Volumes = clientVolumes(instance)
 
 		labels := map[string]string{
 			"app": "openstackclient",
@@ -115,35 +215,82 @@ func (r *OpenStackClientReconciler) reconcileDeployment(instance *controlplanev1
 			Name:      instance.Name,
 			Namespace: instance.Namespace,
 			Labels:    labels,
+			Annotations: map[string]string{
+				configHashAnnotation: configHash,
+			},
 		}
 		clientDeployment.Spec.Template.Spec.Containers = []corev1.Container{
 			{
-				Name:    "openstackclient",
-				Image:   instance.Spec.ContainerImage,
-				Command: []string{"sleep", "infinity"},
-				Env: []corev1.EnvVar{
-					{
-						Name:  "OS_CLOUD",
-						Value: "default",
-					},
-				},
-				VolumeMounts: []corev1.VolumeMount{
-					{
-						Name:      "openstack-config",
-						MountPath: "/etc/openstack/clouds.yaml",
-						SubPath:   "clouds.yaml",
-					},
-					{
-						Name:      "openstack-config-secret",
-						MountPath: "/etc/openstack/secure.yaml",
-						SubPath:   "secure.yaml",
-					},
-				},
+				Name:         "openstackclient",
+				Image:        instance.Spec.ContainerImage,
+				Command:      []string{"sleep", "infinity"},
+				Env:          clientEnv(),
+				VolumeMounts: clientVolumeMounts(),
 			},
 		}
 
-		return nil
+		return controllerutil.SetControllerReference(instance, clientDeployment, r.Scheme)
 	})
 
-	return err
+	return clientDeployment, err
+}
+
+// updateStatus records the rolled out config hash and derives the Available
+// and Ready conditions from the client Deployment
+func (r *OpenStackClientReconciler) updateStatus(instance *controlplanev1beta1.OpenStackClient, clientDeployment *appsv1.Deployment, configHash string) error {
+	instance.Status.DeploymentHash = configHash
+
+	available := controlplanev1beta1.Condition{
+		Type:               controlplanev1beta1.ConditionAvailable,
+		Status:             metav1.ConditionUnknown,
+		ObservedGeneration: instance.Generation,
+		Reason:             "Unknown",
+		Message:            "Deployment reports no Available condition yet",
+	}
+	for _, c := range clientDeployment.Status.Conditions {
+		if c.Type == appsv1.DeploymentAvailable {
+			available.Status = metav1.ConditionStatus(c.Status)
+			available.Reason = c.Reason
+			available.Message = c.Message
+		}
+	}
+	controlplanev1beta1.SetCondition(&instance.Status.Conditions, available)
+
+	ready := controlplanev1beta1.Condition{
+		Type:               controlplanev1beta1.ConditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             "RollingOut",
+		Message:            "waiting for the client pod to be rolled out",
+	}
+	var replicas int32 = 1
+	if clientDeployment.Spec.Replicas != nil {
+		replicas = *clientDeployment.Spec.Replicas
+	}
+	deploymentStatus := clientDeployment.Status
+	if deploymentStatus.ObservedGeneration >= clientDeployment.Generation &&
+		deploymentStatus.UpdatedReplicas == replicas &&
+		deploymentStatus.AvailableReplicas == replicas {
+		ready.Status = metav1.ConditionTrue
+		ready.Reason = "RolledOut"
+		ready.Message = "the client pod runs with the current configuration"
+	} else {
+		// the Deployment changes are watched, no need to requeue
+		r.Log.Info("Client pod not rolled out yet", "Updated", deploymentStatus.UpdatedReplicas, "Available", deploymentStatus.AvailableReplicas)
+	}
+	controlplanev1beta1.SetCondition(&instance.Status.Conditions, ready)
+
+	return r.Client.Status().Update(context.TODO(), instance)
+}
+
+// setNotReadyStatus reports why the client pod can not be deployed in the Ready condition
+func (r *OpenStackClientReconciler) setNotReadyStatus(instance *controlplanev1beta1.OpenStackClient, reason string, err error) error {
+	controlplanev1beta1.SetCondition(&instance.Status.Conditions, controlplanev1beta1.Condition{
+		Type:               controlplanev1beta1.ConditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             reason,
+		Message:            err.Error(),
+	})
+	return r.Client.Status().Update(context.TODO(), instance)
 }
@@ -0,0 +1,288 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/openstack-cluster-operator/pkg/util"
+)
+
+const (
+	// commandHashAnnotation - Job annotation holding the hash of the OpenStackCommand spec it runs
+	commandHashAnnotation = "controlplane.openstack.org/command-hash"
+	// commandContainerName - container of the Job running the commands
+	commandContainerName = "openstackcommand"
+	// commandOutputLimit - bytes of the output kept, the kubelet truncates termination messages to 4096 bytes
+	commandOutputLimit = 4096
+	// defaultCommandBackoffLimit - retries if the OpenStackCommand does not set backoffLimit
+	defaultCommandBackoffLimit int32 = 3
+)
+
+// OpenStackCommandReconciler reconciles a OpenStackCommand object
+type OpenStackCommandReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=controlplane.openstack.org,resources=openstackcommands,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=controlplane.openstack.org,resources=openstackcommands/status,verbs=get;update;patch
+
+// Reconcile OpenStackCommand requests
+func (r *OpenStackCommandReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	_ = r.Log.WithValues("openstackcommand", req.NamespacedName)
+
+	instance := &controlplanev1beta1.OpenStackCommand{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	commandHash, err := util.CalculateHash(instance.Spec)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	job := &batchv1.Job{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, job)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	if err == nil {
+		if job.DeletionTimestamp != nil {
+			// the Job deletion is watched, the new Job gets created once it is gone
+			return ctrl.Result{}, nil
+		}
+		if job.Annotations[commandHashAnnotation] != commandHash {
+			r.Log.Info("Commands changed, replacing Job", "Job", job.Name)
+			err = r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil && !k8s_errors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+			instance.Status = controlplanev1beta1.OpenStackCommandStatus{
+				Phase:   controlplanev1beta1.CommandPending,
+				Message: "commands changed, waiting for the previous Job to be deleted",
+			}
+			return ctrl.Result{}, r.Client.Status().Update(ctx, instance)
+		}
+		return ctrl.Result{}, r.updateStatus(ctx, instance, job)
+	}
+
+	osClient := &controlplanev1beta1.OpenStackClient{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: instance.Spec.OpenStackClient, Namespace: instance.Namespace}, osClient)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// the OpenStackClients are watched, the Job gets created once the client exists
+			instance.Status.Phase = controlplanev1beta1.CommandPending
+			instance.Status.Message = fmt.Sprintf("OpenStackClient %s does not exist", instance.Spec.OpenStackClient)
+			return ctrl.Result{}, r.Client.Status().Update(ctx, instance)
+		}
+		return ctrl.Result{}, err
+	}
+	osClient.Default()
+
+	job = commandJob(instance, osClient, commandHash)
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+	r.Log.Info("Creating Job", "Job", job.Name, "OpenStackClient", osClient.Name)
+	if err := r.Client.Create(ctx, job); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	now := metav1.Now()
+	instance.Status = controlplanev1beta1.OpenStackCommandStatus{
+		Phase:       controlplanev1beta1.CommandRunning,
+		JobName:     job.Name,
+		CommandHash: commandHash,
+		StartTime:   &now,
+	}
+	return ctrl.Result{}, r.Client.Status().Update(ctx, instance)
+}
+
+// SetupWithManager func
+func (r *OpenStackCommandReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// start the pending commands once their OpenStackClient got created
+	clientHandler := &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.clientToRequests)}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&controlplanev1beta1.OpenStackCommand{}).
+		Owns(&batchv1.Job{}).
+		Watches(&source.Kind{Type: &controlplanev1beta1.OpenStackClient{}}, clientHandler).
+		Complete(r)
+}
+
+// clientToRequests maps an OpenStackClient to reconcile requests of the
+// OpenStackCommands in its namespace which run with it
+func (r *OpenStackCommandReconciler) clientToRequests(obj handler.MapObject) []reconcile.Request {
+	commands := &controlplanev1beta1.OpenStackCommandList{}
+	if err := r.Client.List(context.TODO(), commands, client.InNamespace(obj.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "Unable to list OpenStackCommands", "Namespace", obj.Meta.GetNamespace())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, instance := range commands.Items {
+		if instance.Spec.OpenStackClient == obj.Meta.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+// commandJob - Job running the commands with the image and configuration of the OpenStackClient
+func commandJob(instance *controlplanev1beta1.OpenStackCommand, osClient *controlplanev1beta1.OpenStackClient, commandHash string) *batchv1.Job {
+	backoffLimit := defaultCommandBackoffLimit
+	if instance.Spec.BackoffLimit != nil {
+		backoffLimit = *instance.Spec.BackoffLimit
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: instance.Namespace,
+			Annotations: map[string]string{
+				commandHashAnnotation: commandHash,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Volumes:       clientVolumes(osClient),
+					Containers: []corev1.Container{
+						{
+							Name:         commandContainerName,
+							Image:        osClient.Spec.ContainerImage,
+							Command:      []string{"/bin/bash", "-c", commandScript(instance.Spec.Commands)},
+							Env:          clientEnv(),
+							VolumeMounts: clientVolumeMounts(),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// commandScript runs the commands in a subshell stopping at the first
+// failure. The whole output goes to the pod log, its tail is written to the
+// termination message so it can be reported in the status.
+func commandScript(commands []string) string {
+	return fmt.Sprintf(`(
+set -ex
+%s
+) > /tmp/output 2>&1
+rc=$?
+cat /tmp/output
+tail -c %d /tmp/output > /dev/termination-log
+exit $rc
+`, strings.Join(commands, "\n"), commandOutputLimit)
+}
+
+// updateStatus derives the phase from the Job and reports the exit code and
+// output of the last finished attempt
+func (r *OpenStackCommandReconciler) updateStatus(ctx context.Context, instance *controlplanev1beta1.OpenStackCommand, job *batchv1.Job) error {
+	instance.Status.JobName = job.Name
+	instance.Status.CommandHash = job.Annotations[commandHashAnnotation]
+	instance.Status.Attempts = job.Status.Active + job.Status.Succeeded + job.Status.Failed
+	if instance.Status.StartTime == nil {
+		startTime := job.CreationTimestamp
+		instance.Status.StartTime = &startTime
+	}
+
+	instance.Status.Phase = controlplanev1beta1.CommandRunning
+	instance.Status.Message = ""
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			instance.Status.Phase = controlplanev1beta1.CommandSucceeded
+		case batchv1.JobFailed:
+			instance.Status.Phase = controlplanev1beta1.CommandFailed
+			instance.Status.Message = c.Message
+		default:
+			continue
+		}
+		if instance.Status.CompletionTime == nil {
+			completionTime := c.LastTransitionTime
+			instance.Status.CompletionTime = &completionTime
+		}
+	}
+
+	terminated, err := r.lastTerminatedAttempt(ctx, job)
+	if err != nil {
+		return err
+	}
+	if terminated != nil {
+		exitCode := terminated.ExitCode
+		instance.Status.ExitCode = &exitCode
+		instance.Status.Output = terminated.Message
+	}
+
+	return r.Client.Status().Update(ctx, instance)
+}
+
+// lastTerminatedAttempt - state of the most recently finished command container of the Job, nil if none finished yet.
+// The pods get selected by the uid of the Job, the pods of a replaced Job of the same name may still exist.
+func (r *OpenStackCommandReconciler) lastTerminatedAttempt(ctx context.Context, job *batchv1.Job) (*corev1.ContainerStateTerminated, error) {
+	pods := &corev1.PodList{}
+	err := r.Client.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"controller-uid": string(job.UID)})
+	if err != nil {
+		return nil, err
+	}
+
+	var last *corev1.ContainerStateTerminated
+	for i := range pods.Items {
+		for _, cs := range pods.Items[i].Status.ContainerStatuses {
+			if cs.Name != commandContainerName || cs.State.Terminated == nil {
+				continue
+			}
+			if last == nil || last.FinishedAt.Before(&cs.State.Terminated.FinishedAt) {
+				last = cs.State.Terminated
+			}
+		}
+	}
+	return last, nil
+}
@@ -0,0 +1,112 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestLastTerminatedAttempt(t *testing.T) {
+	finished := time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "cmd", Namespace: "openstack", UID: types.UID("new-job")},
+	}
+	pod := func(name string, jobUID types.UID, exitCode int32, finishedAt time.Time) runtime.Object {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "openstack",
+				Labels:    map[string]string{"job-name": "cmd", "controller-uid": string(jobUID)},
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name: commandContainerName,
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, FinishedAt: metav1.NewTime(finishedAt)},
+					},
+				}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		pods         []runtime.Object
+		wantExitCode *int32
+	}{
+		{
+			name:         "no pods",
+			wantExitCode: nil,
+		},
+		{
+			name: "latest attempt",
+			pods: []runtime.Object{
+				pod("cmd-a", "new-job", 1, finished),
+				pod("cmd-b", "new-job", 0, finished.Add(time.Minute)),
+			},
+			wantExitCode: int32Ptr(0),
+		},
+		{
+			name: "pods of a replaced Job are ignored",
+			pods: []runtime.Object{
+				pod("cmd-old", "old-job", 1, finished.Add(time.Minute)),
+			},
+			wantExitCode: nil,
+		},
+		{
+			name: "later pod of a replaced Job",
+			pods: []runtime.Object{
+				pod("cmd-new", "new-job", 0, finished),
+				pod("cmd-old", "old-job", 1, finished.Add(time.Minute)),
+			},
+			wantExitCode: int32Ptr(0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &OpenStackCommandReconciler{Client: fake.NewFakeClient(tt.pods...)}
+
+			got, err := r.lastTerminatedAttempt(context.TODO(), job)
+			if err != nil {
+				t.Fatalf("lastTerminatedAttempt() unexpected error: %v", err)
+			}
+			switch {
+			case tt.wantExitCode == nil && got != nil:
+				t.Errorf("lastTerminatedAttempt() exit code = %d, want none", got.ExitCode)
+			case tt.wantExitCode != nil && got == nil:
+				t.Errorf("lastTerminatedAttempt() = nil, want exit code %d", *tt.wantExitCode)
+			case tt.wantExitCode != nil && got.ExitCode != *tt.wantExitCode:
+				t.Errorf("lastTerminatedAttempt() exit code = %d, want %d", got.ExitCode, *tt.wantExitCode)
+			}
+		})
+	}
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
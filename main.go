@@ -29,6 +29,7 @@ import (
 
 	controlplanev1beta1 "github.com/openstack-k8s-operators/openstack-cluster-operator/api/v1beta1"
 	"github.com/openstack-k8s-operators/openstack-cluster-operator/controllers"
+	bindatautil "github.com/openstack-k8s-operators/openstack-cluster-operator/pkg/bindata_util"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -47,12 +48,26 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var serverSideApply bool
+	var defaultStorageClass string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&serverSideApply, "server-side-apply", false,
+		"Apply the rendered objects using server-side apply instead of get and update.")
+	flag.StringVar(&bindatautil.DefaultApplyOptions.FieldManager, "field-manager", bindatautil.DefaultFieldManager,
+		"Field manager used for server-side apply.")
+	flag.BoolVar(&bindatautil.DefaultApplyOptions.Force, "force-conflicts", false,
+		"Take over fields owned by other field managers on server-side apply instead of failing.")
+	flag.StringVar(&defaultStorageClass, "default-storage-class", "",
+		"Storage class filled in for ControlPlanes which do not set one.")
 	flag.Parse()
 
+	if serverSideApply {
+		bindatautil.DefaultApplyOptions.Mode = bindatautil.ApplyModeServerSide
+	}
+
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
 	namespace, found := os.LookupEnv("WATCH_NAMESPACE")
@@ -61,6 +76,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	// default images of the control plane services, mirrored images on disconnected installs
+	if relatedImages := controllers.LoadRelatedImages(); len(relatedImages) > 0 {
+		setupLog.Info("Using related images", "env", relatedImages)
+	}
+	controlplanev1beta1.SetupControlPlaneDefaults(controlplanev1beta1.ControlPlaneDefaults{
+		StorageClass: defaultStorageClass,
+		Images:       controllers.DefaultImages(),
+	})
+	controlplanev1beta1.SetupOpenStackClientDefaults(controlplanev1beta1.OpenStackClientDefaults{
+		ContainerImage: controllers.DefaultOpenStackClientImage(),
+	})
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
@@ -90,6 +117,24 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "OpenStackClient")
 		os.Exit(1)
 	}
+	if err = (&controllers.OpenStackCommandReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("OpenStackCommand"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "OpenStackCommand")
+		os.Exit(1)
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&controlplanev1beta1.ControlPlane{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ControlPlane")
+			os.Exit(1)
+		}
+		if err = (&controlplanev1beta1.OpenStackClient{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "OpenStackClient")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
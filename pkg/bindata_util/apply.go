@@ -4,19 +4,177 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
 
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	uns "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// ApplyObject applies the desired object against the apiserver,
-// merging it with any existing objects if already present.
+// ApplyMode selects how ApplyObject writes objects to the apiserver
+type ApplyMode string
+
+const (
+	// ApplyModeUpdate gets the existing object, merges the metadata and updates the full object
+	ApplyModeUpdate ApplyMode = "Update"
+	// ApplyModeServerSide uses server-side apply, only the fields set in the desired object are owned
+	ApplyModeServerSide ApplyMode = "ServerSide"
+
+	// DefaultFieldManager - field manager used for server-side apply if none is set
+	DefaultFieldManager = "openstack-cluster-operator"
+)
+
+// ApplyOptions -
+type ApplyOptions struct {
+	// Mode of the apply, defaults to ApplyModeUpdate
+	Mode ApplyMode
+	// FieldManager used for server-side apply, defaults to DefaultFieldManager
+	FieldManager string
+	// Force takes over fields owned by other managers on server-side apply,
+	// instead of failing with an ApplyConflictError
+	Force bool
+}
+
+// DefaultApplyOptions - options used by ApplyObject, meant to be set once on startup
+var DefaultApplyOptions = ApplyOptions{
+	Mode:         ApplyModeUpdate,
+	FieldManager: DefaultFieldManager,
+}
+
+// FieldConflict - a field of an object owned by another field manager
+type FieldConflict struct {
+	// Manager owning the field
+	Manager string
+	// Field path, e.g. .spec.replicas
+	Field string
+	// Message as reported by the apiserver
+	Message string
+}
+
+// ApplyConflictError is returned when a server-side apply without force
+// conflicts with fields owned by other field managers
+type ApplyConflictError struct {
+	// Object the apply was rejected for
+	Object    string
+	Conflicts []FieldConflict
+	err       error
+}
+
+func (e *ApplyConflictError) Error() string {
+	fields := map[string][]string{}
+	for _, c := range e.Conflicts {
+		fields[c.Manager] = append(fields[c.Manager], c.Field)
+	}
+	managers := []string{}
+	for _, manager := range e.Managers() {
+		managers = append(managers, fmt.Sprintf("%s (%s)", manager, strings.Join(fields[manager], ", ")))
+	}
+	return fmt.Sprintf("apply of %s conflicts with field managers %s", e.Object, strings.Join(managers, ", "))
+}
+
+// Unwrap - the original Conflict error of the apiserver
+func (e *ApplyConflictError) Unwrap() error {
+	return e.err
+}
+
+// Managers - the competing field managers, sorted by name
+func (e *ApplyConflictError) Managers() []string {
+	managers := []string{}
+	seen := map[string]bool{}
+	for _, c := range e.Conflicts {
+		if !seen[c.Manager] {
+			seen[c.Manager] = true
+			managers = append(managers, c.Manager)
+		}
+	}
+	sort.Strings(managers)
+	return managers
+}
+
+// conflictManagerRegexp - extracts the manager from the apiserver conflict message,
+// e.g. conflict with "kubectl" using v1
+var conflictManagerRegexp = regexp.MustCompile(`conflict with "([^"]*)"`)
+
+// ApplyObject applies the desired object against the apiserver using the
+// DefaultApplyOptions
 func ApplyObject(ctx context.Context, client k8sclient.Client, obj *uns.Unstructured) error {
+	return ApplyObjectWithOptions(ctx, client, obj, DefaultApplyOptions)
+}
+
+// ApplyObjectWithOptions applies the desired object against the apiserver,
+// either by server-side apply or by merging it with the existing object
+func ApplyObjectWithOptions(ctx context.Context, client k8sclient.Client, obj *uns.Unstructured, opts ApplyOptions) error {
+	if opts.Mode == ApplyModeServerSide {
+		return serverSideApply(ctx, client, obj, opts)
+	}
+	return updateObject(ctx, client, obj)
+}
+
+// serverSideApply applies the desired object using server-side apply
+func serverSideApply(ctx context.Context, client k8sclient.Client, obj *uns.Unstructured, opts ApplyOptions) error {
+	name := obj.GetName()
+	if name == "" {
+		return errors.Errorf("Object %s has no name", obj.GroupVersionKind().String())
+	}
+	objDesc := fmt.Sprintf("(%s) %s/%s", obj.GroupVersionKind().String(), obj.GetNamespace(), name)
+	log.Printf("applying %s", objDesc)
+
+	fieldManager := opts.FieldManager
+	if fieldManager == "" {
+		fieldManager = DefaultFieldManager
+	}
+	patchOpts := []k8sclient.PatchOption{k8sclient.FieldOwner(fieldManager)}
+	if opts.Force {
+		patchOpts = append(patchOpts, k8sclient.ForceOwnership)
+	}
+
+	// the apply configuration must not carry server managed metadata
+	desired := obj.DeepCopy()
+	desired.SetResourceVersion("")
+	desired.SetManagedFields(nil)
+	if err := client.Patch(ctx, desired, k8sclient.Apply, patchOpts...); err != nil {
+		if conflicts := fieldConflicts(err); len(conflicts) > 0 {
+			return &ApplyConflictError{Object: objDesc, Conflicts: conflicts, err: err}
+		}
+		return errors.Wrapf(err, "could not apply %s", objDesc)
+	}
+	obj.Object = desired.Object
+	return nil
+}
+
+// fieldConflicts - the field manager conflicts reported in a Conflict error of the apiserver
+func fieldConflicts(err error) []FieldConflict {
+	if !apierrors.IsConflict(err) {
+		return nil
+	}
+	status, ok := err.(apierrors.APIStatus)
+	if !ok || status.Status().Details == nil {
+		return nil
+	}
+	conflicts := []FieldConflict{}
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		conflict := FieldConflict{Field: cause.Field, Message: cause.Message}
+		if m := conflictManagerRegexp.FindStringSubmatch(cause.Message); m != nil {
+			conflict.Manager = m[1]
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// updateObject applies the desired object against the apiserver,
+// merging it with any existing objects if already present.
+func updateObject(ctx context.Context, client k8sclient.Client, obj *uns.Unstructured) error {
 	name := obj.GetName()
 	namespace := obj.GetNamespace()
 	if name == "" {
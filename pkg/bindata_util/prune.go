@@ -0,0 +1,82 @@
+package bindatautil
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/pkg/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	uns "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PruneAnnotation - objects with this annotation set to "false" are never pruned
+const PruneAnnotation = "controlplane.openstack.org/prune"
+
+// PruneOptions -
+type PruneOptions struct {
+	// DryRun only reports the objects which would get pruned
+	DryRun bool
+	// Namespace the objects get listed in, all namespaces if empty. Listing
+	// across namespaces requires cluster wide list permissions on the kinds.
+	Namespace string
+}
+
+// PruneObjects deletes the objects of the given kinds which match the labels
+// of selector but are not part of the desired objects. It returns the objects
+// which got deleted, or would have been in dry run mode. Kinds unknown to the
+// apiserver are skipped.
+func PruneObjects(ctx context.Context, client k8sclient.Client, gvks []schema.GroupVersionKind, selector map[string]string, desired []*uns.Unstructured, opts PruneOptions) ([]*uns.Unstructured, error) {
+	keep := map[string]bool{}
+	for _, obj := range desired {
+		keep[objectKey(obj)] = true
+	}
+
+	pruned := []*uns.Unstructured{}
+	for _, gvk := range gvks {
+		list := &uns.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		listOpts := []k8sclient.ListOption{k8sclient.MatchingLabels(selector)}
+		if opts.Namespace != "" {
+			listOpts = append(listOpts, k8sclient.InNamespace(opts.Namespace))
+		}
+		if err := client.List(ctx, list, listOpts...); err != nil {
+			if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+				continue
+			}
+			return pruned, errors.Wrapf(err, "could not list %s", gvk.String())
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if keep[objectKey(obj)] || obj.GetAnnotations()[PruneAnnotation] == "false" {
+				continue
+			}
+			if obj.GetDeletionTimestamp() != nil {
+				continue
+			}
+
+			objDesc := fmt.Sprintf("(%s) %s/%s", gvk.String(), obj.GetNamespace(), obj.GetName())
+			pruned = append(pruned, obj)
+			if opts.DryRun {
+				log.Printf("would prune %s (dry run)", objDesc)
+				continue
+			}
+			log.Printf("pruning %s", objDesc)
+			if err := client.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+				return pruned, errors.Wrapf(err, "could not delete %s", objDesc)
+			}
+		}
+	}
+	return pruned, nil
+}
+
+// objectKey - identifies an object independent of the version of its kind
+func objectKey(obj *uns.Unstructured) string {
+	gk := obj.GroupVersionKind().GroupKind()
+	return fmt.Sprintf("%s/%s/%s", gk.String(), obj.GetNamespace(), obj.GetName())
+}
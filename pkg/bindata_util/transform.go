@@ -0,0 +1,101 @@
+package bindatautil
+
+import (
+	"strings"
+
+	uns "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ObjectTransform modifies a rendered object before it gets applied
+type ObjectTransform func(obj *uns.Unstructured) error
+
+// TransformObjects runs all transforms, in order, on each of the objects
+func TransformObjects(objs []*uns.Unstructured, transforms ...ObjectTransform) error {
+	for _, obj := range objs {
+		for _, transform := range transforms {
+			if err := transform(obj); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ImageRewrite defines how container image references get rewritten
+type ImageRewrite struct {
+	// Prefix replaces the registry and namespace of the image,
+	// e.g. registry.example.com/mirror
+	Prefix string
+	// Tag replaces the tag of the image, images referenced
+	// by digest are kept as is
+	Tag string
+}
+
+// RewriteImages returns an ObjectTransform which rewrites all container
+// image references of an object. As the rendered objects are mostly custom
+// resources of other operators, any string field below spec with a key
+// ending in "image" (case insensitive) is considered an image reference,
+// like the image of a pod spec container or a containerImage field of a CR.
+// Objects without spec, like Secrets and ConfigMaps, are kept as is.
+func RewriteImages(rewrite ImageRewrite) ObjectTransform {
+	return func(obj *uns.Unstructured) error {
+		if rewrite.Prefix == "" && rewrite.Tag == "" {
+			return nil
+		}
+		if spec, ok := obj.Object["spec"]; ok {
+			obj.Object["spec"] = rewriteImageFields(spec, rewrite)
+		}
+		return nil
+	}
+}
+
+func rewriteImageFields(val interface{}, rewrite ImageRewrite) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if s, ok := field.(string); ok && strings.HasSuffix(strings.ToLower(key), "image") {
+				v[key] = rewrite.Apply(s)
+				continue
+			}
+			v[key] = rewriteImageFields(field, rewrite)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = rewriteImageFields(v[i], rewrite)
+		}
+	}
+	return val
+}
+
+// Apply returns the rewritten image reference
+func (rewrite ImageRewrite) Apply(image string) string {
+	if image == "" {
+		return image
+	}
+
+	// split off the digest or tag, a colon before the last slash separates the registry port
+	name, tag, digest := image, "", ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, digest = name[:i], name[i:]
+	}
+	if i := strings.LastIndex(name, ":"); i >= 0 && i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+	}
+
+	if rewrite.Prefix != "" {
+		name = strings.TrimSuffix(rewrite.Prefix, "/") + "/" + name[strings.LastIndex(name, "/")+1:]
+	}
+	if digest != "" {
+		if tag != "" {
+			return name + ":" + tag + digest
+		}
+		return name + digest
+	}
+	if rewrite.Tag != "" {
+		tag = rewrite.Tag
+	}
+	if tag != "" {
+		return name + ":" + tag
+	}
+	return name
+}
@@ -0,0 +1,231 @@
+package bindatautil
+
+import (
+	"reflect"
+	"testing"
+
+	uns "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestImageRewriteApply(t *testing.T) {
+	const digest = "@sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945"
+
+	tests := []struct {
+		name    string
+		rewrite ImageRewrite
+		image   string
+		want    string
+	}{
+		{
+			name:  "no rewrite",
+			image: "quay.io/tripleotrain/centos-binary-keystone:current-tripleo",
+			want:  "quay.io/tripleotrain/centos-binary-keystone:current-tripleo",
+		},
+		{
+			name:    "empty image",
+			rewrite: ImageRewrite{Prefix: "registry.example.com/mirror", Tag: "v1"},
+			image:   "",
+			want:    "",
+		},
+		{
+			name:    "prefix",
+			rewrite: ImageRewrite{Prefix: "registry.example.com/mirror"},
+			image:   "quay.io/tripleotrain/centos-binary-keystone:current-tripleo",
+			want:    "registry.example.com/mirror/centos-binary-keystone:current-tripleo",
+		},
+		{
+			name:    "prefix with trailing slash",
+			rewrite: ImageRewrite{Prefix: "registry.example.com/mirror/"},
+			image:   "quay.io/tripleotrain/centos-binary-keystone:current-tripleo",
+			want:    "registry.example.com/mirror/centos-binary-keystone:current-tripleo",
+		},
+		{
+			name:    "prefix with registry port",
+			rewrite: ImageRewrite{Prefix: "registry.example.com:5000/mirror"},
+			image:   "quay.io/tripleotrain/centos-binary-keystone:current-tripleo",
+			want:    "registry.example.com:5000/mirror/centos-binary-keystone:current-tripleo",
+		},
+		{
+			name:    "prefix of image without namespace",
+			rewrite: ImageRewrite{Prefix: "registry.example.com/mirror"},
+			image:   "keystone:current-tripleo",
+			want:    "registry.example.com/mirror/keystone:current-tripleo",
+		},
+		{
+			name:    "tag",
+			rewrite: ImageRewrite{Tag: "v1"},
+			image:   "quay.io/tripleotrain/centos-binary-keystone:current-tripleo",
+			want:    "quay.io/tripleotrain/centos-binary-keystone:v1",
+		},
+		{
+			name:    "tag of untagged image",
+			rewrite: ImageRewrite{Tag: "v1"},
+			image:   "quay.io/tripleotrain/centos-binary-keystone",
+			want:    "quay.io/tripleotrain/centos-binary-keystone:v1",
+		},
+		{
+			name:    "tag of image with registry port",
+			rewrite: ImageRewrite{Tag: "v1"},
+			image:   "registry.example.com:5000/tripleotrain/centos-binary-keystone:current-tripleo",
+			want:    "registry.example.com:5000/tripleotrain/centos-binary-keystone:v1",
+		},
+		{
+			name:    "tag of untagged image with registry port",
+			rewrite: ImageRewrite{Tag: "v1"},
+			image:   "registry.example.com:5000/tripleotrain/centos-binary-keystone",
+			want:    "registry.example.com:5000/tripleotrain/centos-binary-keystone:v1",
+		},
+		{
+			name:    "prefix and tag of image with registry port",
+			rewrite: ImageRewrite{Prefix: "registry.example.com/mirror", Tag: "v1"},
+			image:   "quay.io:443/tripleotrain/centos-binary-keystone:current-tripleo",
+			want:    "registry.example.com/mirror/centos-binary-keystone:v1",
+		},
+		{
+			name:    "digest keeps the image",
+			rewrite: ImageRewrite{Tag: "v1"},
+			image:   "quay.io/tripleotrain/centos-binary-keystone" + digest,
+			want:    "quay.io/tripleotrain/centos-binary-keystone" + digest,
+		},
+		{
+			name:    "prefix of image with digest",
+			rewrite: ImageRewrite{Prefix: "registry.example.com/mirror", Tag: "v1"},
+			image:   "quay.io/tripleotrain/centos-binary-keystone" + digest,
+			want:    "registry.example.com/mirror/centos-binary-keystone" + digest,
+		},
+		{
+			name:    "digest plus tag keeps both",
+			rewrite: ImageRewrite{Tag: "v1"},
+			image:   "quay.io/tripleotrain/centos-binary-keystone:current-tripleo" + digest,
+			want:    "quay.io/tripleotrain/centos-binary-keystone:current-tripleo" + digest,
+		},
+		{
+			name:    "prefix of image with digest plus tag and registry port",
+			rewrite: ImageRewrite{Prefix: "registry.example.com:5000/mirror/", Tag: "v1"},
+			image:   "quay.io:443/tripleotrain/centos-binary-keystone:current-tripleo" + digest,
+			want:    "registry.example.com:5000/mirror/centos-binary-keystone:current-tripleo" + digest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rewrite.Apply(tt.image); got != tt.want {
+				t.Errorf("Apply(%q) = %q, want %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteImages(t *testing.T) {
+	rewrite := ImageRewrite{Prefix: "registry.example.com/mirror", Tag: "v1"}
+
+	tests := []struct {
+		name string
+		obj  map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			name: "image fields of a CR",
+			obj: map[string]interface{}{
+				"kind": "KeystoneAPI",
+				"spec": map[string]interface{}{
+					"containerImage": "quay.io/tripleotrain/centos-binary-keystone:current-tripleo",
+					"replicas":       int64(1),
+				},
+			},
+			want: map[string]interface{}{
+				"kind": "KeystoneAPI",
+				"spec": map[string]interface{}{
+					"containerImage": "registry.example.com/mirror/centos-binary-keystone:v1",
+					"replicas":       int64(1),
+				},
+			},
+		},
+		{
+			name: "containers of a pod template",
+			obj: map[string]interface{}{
+				"kind": "Deployment",
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"containers": []interface{}{
+								map[string]interface{}{"name": "client", "image": "quay.io/tripleotrain/centos-binary-openstackclient:current-tripleo"},
+							},
+						},
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"kind": "Deployment",
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"containers": []interface{}{
+								map[string]interface{}{"name": "client", "image": "registry.example.com/mirror/centos-binary-openstackclient:v1"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "image fields outside of spec",
+			obj: map[string]interface{}{
+				"kind":     "MariaDB",
+				"metadata": map[string]interface{}{"annotations": map[string]interface{}{"example.com/image": "quay.io/tripleotrain/centos-binary-mariadb:current-tripleo"}},
+			},
+			want: map[string]interface{}{
+				"kind":     "MariaDB",
+				"metadata": map[string]interface{}{"annotations": map[string]interface{}{"example.com/image": "quay.io/tripleotrain/centos-binary-mariadb:current-tripleo"}},
+			},
+		},
+		{
+			name: "secret",
+			obj: map[string]interface{}{
+				"kind": "Secret",
+				"stringData": map[string]interface{}{
+					"DefaultImage": "quay.io/tripleotrain/centos-binary-nova-api:current-tripleo",
+				},
+				"data": map[string]interface{}{
+					"image": "cXVheS5pby90cmlwbGVvdHJhaW4vY2VudG9zLWJpbmFyeS1ub3ZhLWFwaQ==",
+				},
+			},
+			want: map[string]interface{}{
+				"kind": "Secret",
+				"stringData": map[string]interface{}{
+					"DefaultImage": "quay.io/tripleotrain/centos-binary-nova-api:current-tripleo",
+				},
+				"data": map[string]interface{}{
+					"image": "cXVheS5pby90cmlwbGVvdHJhaW4vY2VudG9zLWJpbmFyeS1ub3ZhLWFwaQ==",
+				},
+			},
+		},
+		{
+			name: "configmap",
+			obj: map[string]interface{}{
+				"kind": "ConfigMap",
+				"data": map[string]interface{}{
+					"image": "quay.io/tripleotrain/centos-binary-nova-api:current-tripleo",
+				},
+			},
+			want: map[string]interface{}{
+				"kind": "ConfigMap",
+				"data": map[string]interface{}{
+					"image": "quay.io/tripleotrain/centos-binary-nova-api:current-tripleo",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &uns.Unstructured{Object: tt.obj}
+			if err := RewriteImages(rewrite)(obj); err != nil {
+				t.Fatalf("RewriteImages() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(obj.Object, tt.want) {
+				t.Errorf("RewriteImages() = %v, want %v", obj.Object, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package operator
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/blang/semver"
@@ -16,7 +18,60 @@ import (
 
 const openstackClusterName = "openstack-cluster-operator"
 
-func getDeploymentSpec(namespace, image, imagePullPolicy string) appsv1.DeploymentSpec {
+// RelatedImage - an image referenced by the operator, mirrored by OLM on disconnected installs
+type RelatedImage struct {
+	Name  string
+	Image string
+}
+
+var relatedImageEnvVarInvalidChars = regexp.MustCompile("[^A-Z0-9_]")
+
+// RelatedImageEnvVar returns the name of the environment variable the
+// operator reads the related image with the given name from, e.g.
+// glance-api -> RELATED_IMAGE_GLANCE_API
+func RelatedImageEnvVar(name string) string {
+	return "RELATED_IMAGE_" + relatedImageEnvVarInvalidChars.ReplaceAllString(strings.ToUpper(name), "_")
+}
+
+func getDeploymentSpec(namespace, image, imagePullPolicy string, relatedImages []RelatedImage) appsv1.DeploymentSpec {
+	env := []corev1.EnvVar{
+		{
+			Name:  "OPERATOR_IMAGE",
+			Value: image,
+		},
+		{
+			Name:  "OPERATOR_NAME",
+			Value: openstackClusterName,
+		},
+		{
+			Name: "POD_NAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: "metadata.name",
+				},
+			},
+		},
+		{
+			Name: "WATCH_NAMESPACE",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: "metadata.namespace",
+				},
+			},
+		},
+		{
+			// the CSV does not define the webhooks yet
+			Name:  "ENABLE_WEBHOOKS",
+			Value: "false",
+		},
+	}
+	for _, relatedImage := range relatedImages {
+		env = append(env, corev1.EnvVar{
+			Name:  RelatedImageEnvVar(relatedImage.Name),
+			Value: relatedImage.Image,
+		})
+	}
+
 	return appsv1.DeploymentSpec{
 		Replicas: int32Ptr(1),
 		Selector: &metav1.LabelSelector{
@@ -37,32 +92,7 @@ func getDeploymentSpec(namespace, image, imagePullPolicy string) appsv1.Deployme
 						Name:            openstackClusterName,
 						Image:           image,
 						ImagePullPolicy: corev1.PullPolicy(imagePullPolicy),
-						Env: []corev1.EnvVar{
-							{
-								Name:  "OPERATOR_IMAGE",
-								Value: image,
-							},
-							{
-								Name:  "OPERATOR_NAME",
-								Value: openstackClusterName,
-							},
-							{
-								Name: "POD_NAME",
-								ValueFrom: &corev1.EnvVarSource{
-									FieldRef: &corev1.ObjectFieldSelector{
-										FieldPath: "metadata.name",
-									},
-								},
-							},
-							{
-								Name: "WATCH_NAMESPACE",
-								ValueFrom: &corev1.EnvVarSource{
-									FieldRef: &corev1.ObjectFieldSelector{
-										FieldPath: "metadata.namespace",
-									},
-								},
-							},
-						},
+						Env:             env,
 					},
 				},
 			},
@@ -103,6 +133,28 @@ func getOperatorRules() *[]rbacv1.PolicyRule {
 				"create",
 			},
 		},
+		{
+			APIGroups: []string{
+				"apps",
+			},
+			Resources: []string{
+				"deployments",
+			},
+			Verbs: []string{
+				"*",
+			},
+		},
+		{
+			APIGroups: []string{
+				"batch",
+			},
+			Resources: []string{
+				"jobs",
+			},
+			Verbs: []string{
+				"*",
+			},
+		},
 		{
 			APIGroups: []string{
 				"apps",
@@ -125,6 +177,7 @@ func getOperatorRules() *[]rbacv1.PolicyRule {
 				"*",
 				"controlplanes",
 				"openstackclients",
+				"openstackcommands",
 			},
 			Verbs: []string{
 				"*",
@@ -263,15 +316,37 @@ func getOperatorRules() *[]rbacv1.PolicyRule {
 	}
 }
 
-// GetInstallStrategyBase returns the cluster base strategy
-func GetInstallStrategyBase(namespace, image, imagePullPolicy string) csvv1alpha1.StrategyDetailsDeployment {
+// getOperatorClusterRules - rules on cluster scoped resources, the storage
+// classes tell whether the PVCs of the services can be expanded
+func getOperatorClusterRules() *[]rbacv1.PolicyRule {
+	return &[]rbacv1.PolicyRule{
+		{
+			APIGroups: []string{
+				"storage.k8s.io",
+			},
+			Resources: []string{
+				"storageclasses",
+			},
+			Verbs: []string{
+				"get",
+				"list",
+				"watch",
+			},
+		},
+	}
+}
+
+// GetInstallStrategyBase returns the cluster base strategy, the related images
+// get passed to the operator as RELATED_IMAGE_<NAME> environment variables
+func GetInstallStrategyBase(namespace, image, imagePullPolicy string, relatedImages []RelatedImage) csvv1alpha1.StrategyDetailsDeployment {
 	rules := getOperatorRules()
+	clusterRules := getOperatorClusterRules()
 
 	return csvv1alpha1.StrategyDetailsDeployment{
 		DeploymentSpecs: []csvv1alpha1.StrategyDeploymentSpec{
 			csvv1alpha1.StrategyDeploymentSpec{
 				Name: "openstack-cluster-operator",
-				Spec: getDeploymentSpec(namespace, image, imagePullPolicy),
+				Spec: getDeploymentSpec(namespace, image, imagePullPolicy, relatedImages),
 			},
 		},
 		Permissions: []csvv1alpha1.StrategyDeploymentPermissions{
@@ -280,6 +355,12 @@ func GetInstallStrategyBase(namespace, image, imagePullPolicy string) csvv1alpha
 				Rules:              *rules,
 			},
 		},
+		ClusterPermissions: []csvv1alpha1.StrategyDeploymentPermissions{
+			{
+				ServiceAccountName: "openstack-cluster-operator",
+				Rules:              *clusterRules,
+			},
+		},
 	}
 }
 
@@ -463,6 +544,13 @@ func GetCSVBase(name, namespace, displayName, description, image, replaces strin
 						DisplayName: "OpenStack Client",
 						Description: "Represents a OpenStack Client Deployment for the " + crdDisplay,
 					},
+					csvv1alpha1.CRDDescription{
+						Name:        "openstackcommands.controlplane.openstack.org",
+						Version:     "v1beta1",
+						Kind:        "OpenStackCommand",
+						DisplayName: "OpenStack Command",
+						Description: "Represents OpenStack CLI commands run as a Job for the " + crdDisplay,
+					},
 				},
 				Required: []csvv1alpha1.CRDDescription{},
 			},
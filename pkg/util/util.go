@@ -2,8 +2,10 @@ package util
 
 import (
 	"crypto/md5"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"math/big"
 )
 
 // CalculateHash computes MD5 sum of the JSONfied object passed as obj.
@@ -15,3 +17,21 @@ func CalculateHash(obj interface{}) (string, error) {
 	configSum := md5.Sum(configStr)
 	return fmt.Sprintf("%x", configSum), nil
 }
+
+const passwordChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// GeneratePassword returns a cryptographically random alphanumeric string of
+// the given length. Only alphanumeric chars are used so the result can be
+// embedded as is in URLs like the AMQP transport url.
+func GeneratePassword(length int) (string, error) {
+	max := big.NewInt(int64(len(passwordChars)))
+	password := make([]byte, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		password[i] = passwordChars[n.Int64()]
+	}
+	return string(password), nil
+}
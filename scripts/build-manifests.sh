@@ -37,6 +37,32 @@ OPERATOR_NAME="${NAME:-openstack-cluster-operator}"
 OPERATOR_NAMESPACE="${NAMESPACE:-openstack}"
 OPERATOR_IMAGE="${OPERATOR_IMAGE:-quay.io/openstack-k8s-operators/openstack-cluster-operator:v0.0.1}"
 IMAGE_PULL_POLICY="${IMAGE_PULL_POLICY:-IfNotPresent}"
+# Comma separated 'image|name' list of the service images, passed to the operator as RELATED_IMAGE_<NAME>.
+# The names have to match the RelatedImage names the operator reads, see controllers/controlplane_services.go
+# and the OpenStackClient image in controllers/controlplane_images.go:
+#   mariadb, keystone, glance-api, placement-api, neutron-server, nova-api, nova-scheduler,
+#   nova-conductor, nova-metadata, nova-novncproxy, cinder-api, cinder-scheduler, cinder-backup,
+#   cinder-volume, openstackclient
+# Without a name the csv-merger uses the last path element of the image, including its tag.
+SERVICE_IMAGE_REGISTRY="${SERVICE_IMAGE_REGISTRY:-quay.io/tripleotrain}"
+SERVICE_IMAGE_TAG="${SERVICE_IMAGE_TAG:-current-tripleo}"
+DEFAULT_RELATED_IMAGES="\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-mariadb:${SERVICE_IMAGE_TAG}|mariadb,\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-keystone:${SERVICE_IMAGE_TAG}|keystone,\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-glance-api:${SERVICE_IMAGE_TAG}|glance-api,\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-placement-api:${SERVICE_IMAGE_TAG}|placement-api,\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-neutron-server-ovn:${SERVICE_IMAGE_TAG}|neutron-server,\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-nova-api:${SERVICE_IMAGE_TAG}|nova-api,\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-nova-scheduler:${SERVICE_IMAGE_TAG}|nova-scheduler,\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-nova-conductor:${SERVICE_IMAGE_TAG}|nova-conductor,\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-nova-api:${SERVICE_IMAGE_TAG}|nova-metadata,\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-nova-novncproxy:${SERVICE_IMAGE_TAG}|nova-novncproxy,\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-cinder-api:${SERVICE_IMAGE_TAG}|cinder-api,\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-cinder-scheduler:${SERVICE_IMAGE_TAG}|cinder-scheduler,\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-cinder-backup:${SERVICE_IMAGE_TAG}|cinder-backup,\
+${SERVICE_IMAGE_REGISTRY}/centos-binary-cinder-volume:${SERVICE_IMAGE_TAG}|cinder-volume,\
+quay.io/openstack-k8s-operators/tripleo-deploy:latest|openstackclient"
+RELATED_IMAGES="${RELATED_IMAGES:-${DEFAULT_RELATED_IMAGES}}"
 
 # Component Images
 NOVA_IMAGE="${NOVA_IMAGE:-quay.io/openstack-k8s-operators/nova-operator:v0.0.3}"
@@ -271,6 +297,7 @@ ${PROJECT_ROOT}/bin/csv-merger \
   --crd-display="OpenStack Cluster Operator" \
   -csv-overrides="$(<${csvOverrides})" \
   --namespace="${OPERATOR_NAMESPACE}" \
+  --related-images-list="${RELATED_IMAGES}" \
   --operator-image-name="${OPERATOR_IMAGE}" > "${CSV_DIR}/${OPERATOR_NAME}.v${CSV_VERSION}.${CSV_EXT}"
 (cd ${PROJECT_ROOT}/tools/csv-merger/ && go clean)
 
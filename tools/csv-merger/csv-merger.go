@@ -88,7 +88,7 @@ var (
 	namespace           = flag.String("namespace", "openstack", "Namespace")
 	crdDisplay          = flag.String("crd-display", "OpenStack Cluster", "Label show in OLM UI about the primary CRD")
 	csvOverrides        = flag.String("csv-overrides", "", "CSV like string with punctual changes that will be recursively applied (if possible)")
-	visibleCRDList      = flag.String("visible-crds-list", "controlplanes.controlplane.openstack.org,computenodeopenstacks.compute-node.openstack.org,openstackclients.controlplane.openstack.org",
+	visibleCRDList      = flag.String("visible-crds-list", "controlplanes.controlplane.openstack.org,computenodeopenstacks.compute-node.openstack.org,openstackclients.controlplane.openstack.org,openstackcommands.controlplane.openstack.org",
 		"Comma separated list of all the CRDs that should be visible in OLM console")
 	relatedImagesList = flag.String("related-images-list", "",
 		"Comma separated list of all the images referred in the CSV (just the image pull URLs or eventually a set of 'image|name' collations)")
@@ -244,13 +244,7 @@ func main() {
 			Spec:       clusterServiceVersionSpecExtended{ClusterServiceVersionSpec: csvBase.Spec},
 			Status:     csvBase.Status}
 
-		// This is the base deployment + rbac for the OpenStack Cluster CSV
-		installStrategyBase := operator.GetInstallStrategyBase(
-			*namespace,
-			*operatorImage,
-			"IfNotPresent",
-		)
-
+		relatedImages := []operator.RelatedImage{}
 		for _, image := range strings.Split(*relatedImagesList, ",") {
 			if image != "" {
 				name := ""
@@ -268,9 +262,23 @@ func main() {
 						Name: name,
 						Ref:  image,
 					})
+				relatedImages = append(
+					relatedImages,
+					operator.RelatedImage{
+						Name:  name,
+						Image: image,
+					})
 			}
 		}
 
+		// This is the base deployment + rbac for the OpenStack Cluster CSV
+		installStrategyBase := operator.GetInstallStrategyBase(
+			*namespace,
+			*operatorImage,
+			"IfNotPresent",
+			relatedImages,
+		)
+
 		for _, csvStr := range csvs {
 			if csvStr != "" {
 				csvBytes := []byte(csvStr)